@@ -1,8 +1,10 @@
 package robomaster
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"reflect"
 	"sync"
 	"time"
@@ -18,6 +20,7 @@ import (
 	"github.com/brunoga/robomaster/module/robot"
 	"github.com/brunoga/robomaster/module/sdcard"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/registry"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/wrapper"
 )
@@ -49,25 +52,72 @@ type Client struct {
 // To get a robot to broadcast a given appID, use a QRCode to configure it (see
 // https://github.com/brunoga/robomaster/unitybridge/blob/main/support/qrcode/qrcode.go).
 func New(l *logger.Logger, appID uint64) (*Client, error) {
-	return new(l, appID, connection.TypeRouter, module.TypeAll)
+	return new(l, appID, connection.TypeRouter, nil, module.TypeAll)
 }
 
 // NewWithModules is like New but allows selecting which mkodules to enable.
 // The Connection and Robot modules are required.
 func NewWithModules(l *logger.Logger, appID uint64,
 	modules module.Type) (*Client, error) {
-	return new(l, appID, connection.TypeRouter, modules)
+	return new(l, appID, connection.TypeRouter, nil, modules)
 }
 
 // NewWifiDirect creates a new Client instance with the given logger. This
 // client will connect to the robot using WiFi Direct.
 func NewWifiDirect(l *logger.Logger) (*Client, error) {
-	return new(l, 0, connection.TypeWiFiDirect, module.TypeAllButGamePad)
+	return new(l, 0, connection.TypeWiFiDirect, nil, module.TypeAllButGamePad)
 }
 
 func NewWifiDirectWithModules(l *logger.Logger,
 	modules module.Type) (*Client, error) {
-	return new(l, 0, connection.TypeWiFiDirect, modules)
+	return new(l, 0, connection.TypeWiFiDirect, nil, modules)
+}
+
+// NewWithIP creates a new Client instance with the given logger. This client
+// will connect directly to the robot at the given IP (useful in networks that
+// block the broadcasts used for robot discovery).
+func NewWithIP(l *logger.Logger, ip net.IP) (*Client, error) {
+	return new(l, 0, connection.TypeStaticIP, ip, module.TypeAll)
+}
+
+// NewWithIPWithModules is like NewWithIP but allows selecting which modules
+// to enable. The Connection and Robot modules are required.
+func NewWithIPWithModules(l *logger.Logger, ip net.IP,
+	modules module.Type) (*Client, error) {
+	return new(l, 0, connection.TypeStaticIP, ip, modules)
+}
+
+// NewUSB creates a new Client instance with the given logger. This client will
+// connect to the robot using the USB (RNDIS) link available in the EP.
+func NewUSB(l *logger.Logger) (*Client, error) {
+	return new(l, 0, connection.TypeUSB, nil, module.TypeAllButGamePad)
+}
+
+// NewUSBWithModules is like NewUSB but allows selecting which modules to
+// enable. The Connection and Robot modules are required.
+func NewUSBWithModules(l *logger.Logger, modules module.Type) (*Client,
+	error) {
+	return new(l, 0, connection.TypeUSB, nil, modules)
+}
+
+// NewFromRegistry creates a new Client instance with the given logger. This
+// client will connect to the robot with the given name in the given registry
+// (i.e. using the appID it was paired with).
+func NewFromRegistry(l *logger.Logger, r *registry.Registry,
+	name string) (*Client, error) {
+	return NewFromRegistryWithModules(l, r, name, module.TypeAll)
+}
+
+// NewFromRegistryWithModules is like NewFromRegistry but allows selecting
+// which modules to enable. The Connection and Robot modules are required.
+func NewFromRegistryWithModules(l *logger.Logger, r *registry.Registry,
+	name string, modules module.Type) (*Client, error) {
+	rr, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithModules(l, rr.AppID, modules)
 }
 
 // Start starts the client and all associated modules.
@@ -137,9 +187,9 @@ func (c *Client) Start() error {
 
 	// Gun.
 	go func() {
-		err = c.changeStateIfNonNil(c.gunModule, waitTimeout, true)
+		err := c.changeStateIfNonNil(c.gunModule, waitTimeout, true)
 		if err != nil {
-			if err.Error() == "Gun connection not established" {
+			if errors.Is(err, module.ErrConnectionNotEstablished) {
 				// Gun is optional so it is fine it did not connect.
 				c.l.Warn("Gun connection not established.")
 			} else {
@@ -150,9 +200,9 @@ func (c *Client) Start() error {
 
 	// GamePad.
 	go func() {
-		err = c.changeStateIfNonNil(c.gamePadModule, waitTimeout, true)
+		err := c.changeStateIfNonNil(c.gamePadModule, waitTimeout, true)
 		if err != nil {
-			if err.Error() == "GamePad connection not established" {
+			if errors.Is(err, module.ErrConnectionNotEstablished) {
 				// GamePad is optional so it is fine it did not connect.
 				c.l.Warn("GamePad connection not established.")
 			} else {
@@ -281,7 +331,7 @@ func (c *Client) Stop() error {
 	return nil
 }
 
-func new(l *logger.Logger, appID uint64, typ connection.Type,
+func new(l *logger.Logger, appID uint64, typ connection.Type, ip net.IP,
 	modules module.Type) (*Client, error) {
 	if l == nil {
 		l = logger.New(slog.LevelError)
@@ -300,7 +350,13 @@ func new(l *logger.Logger, appID uint64, typ connection.Type,
 
 	ub := unitybridge.Get(wrapper.Get(l), unityBridgeDebugEnabled, l)
 
-	connectionModule, err := connection.New(ub, l, appID, typ)
+	var connectionModule *connection.Connection
+	var err error
+	if typ == connection.TypeStaticIP {
+		connectionModule, err = connection.NewWithIP(ub, l, ip)
+	} else {
+		connectionModule, err = connection.New(ub, l, appID, typ)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -399,7 +455,7 @@ func (c *Client) changeStateIfNonNil(m module.Module, waitTime time.Duration,
 	}
 
 	if start && !m.WaitForConnection(waitTime) {
-		return fmt.Errorf("%s connection not established", m)
+		return fmt.Errorf("%s %w", m, module.ErrConnectionNotEstablished)
 	}
 
 	return nil
@@ -0,0 +1,35 @@
+package camera
+
+// AntiFlicker is the camera anti-flicker setting, used to avoid banding under
+// artificial lighting.
+//
+// The values follow the anti-flicker frequency enum used by the DJI Mobile SDK
+// (DJICameraAntiFlickerFrequency: Auto = 0, 60Hz = 1, 50Hz = 2). That the
+// robot uses the same mapping for KeyCameraAntiFlicker was not verified.
+type AntiFlicker uint8
+
+const (
+	AntiFlickerAuto AntiFlicker = iota
+	AntiFlicker60Hz
+	AntiFlicker50Hz
+	AntiFlickerCount
+)
+
+// String returns the anti-flicker setting as a string.
+func (af AntiFlicker) String() string {
+	switch af {
+	case AntiFlickerAuto:
+		return "Auto"
+	case AntiFlicker60Hz:
+		return "60Hz"
+	case AntiFlicker50Hz:
+		return "50Hz"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the anti-flicker setting is valid.
+func (af AntiFlicker) Valid() bool {
+	return af < AntiFlickerCount
+}
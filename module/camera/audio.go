@@ -0,0 +1,85 @@
+package camera
+
+import (
+	"fmt"
+	"time"
+)
+
+// AudioFormat describes the PCM format of audio received from the robot.
+type AudioFormat struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+}
+
+// DefaultAudioFormat is the audio format assumed for audio data received from
+// the robot. This is an unverified assumption. Use SetAudioFormat if the robot
+// produces something else.
+var DefaultAudioFormat = AudioFormat{
+	SampleRate:    48000,
+	Channels:      1,
+	BitsPerSample: 16,
+}
+
+// FrameSize returns the size in bytes of a single (multi-channel) sample.
+func (af AudioFormat) FrameSize() int {
+	return af.Channels * af.BitsPerSample / 8
+}
+
+// Valid returns true if the audio format is valid.
+func (af AudioFormat) Valid() bool {
+	return af.SampleRate > 0 && af.Channels > 0 &&
+		af.BitsPerSample > 0 && af.BitsPerSample%8 == 0
+}
+
+// AudioFrame is a chunk of audio received from the robot.
+type AudioFrame struct {
+	// Samples are the interleaved little endian PCM samples. They are shared
+	// between all callbacks and must not be modified. They remain valid after
+	// the callback returns.
+	Samples []byte
+
+	// Format is the format of the samples.
+	Format AudioFormat
+
+	// Sequence is the sequence number of the audio frame.
+	Sequence uint64
+
+	// Time is the time the audio frame was received.
+	Time time.Time
+}
+
+// Duration returns the duration of the audio in the frame.
+func (af *AudioFrame) Duration() time.Duration {
+	frameSize := af.Format.FrameSize()
+	if frameSize == 0 || af.Format.SampleRate == 0 {
+		return 0
+	}
+
+	samples := len(af.Samples) / frameSize
+
+	return time.Duration(samples) * time.Second /
+		time.Duration(af.Format.SampleRate)
+}
+
+// AudioCallback is the type of the callback function used to receive audio
+// frames.
+type AudioCallback func(frame *AudioFrame)
+
+// newAudioFrame returns an audio frame holding a copy of the given data (which
+// is only valid during event handling) with the given format. Returns an error
+// if the data is not a whole number of samples.
+func newAudioFrame(data []byte, format AudioFormat, sequence uint64,
+	t time.Time) (*AudioFrame, error) {
+	if len(data) == 0 || len(data)%format.FrameSize() != 0 {
+		return nil, fmt.Errorf("unexpected audio data size for format %+v: "+
+			"%d", format, len(data))
+	}
+
+	return &AudioFrame{
+		Samples:  append([]byte(nil), data...),
+		Format:   format,
+		Sequence: sequence,
+		Time:     t,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package camera
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAudioFrame(t *testing.T) {
+	af, err := newAudioFrame(make([]byte, 9600), DefaultAudioFormat, 1,
+		time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := af.Duration(); d != 100*time.Millisecond {
+		t.Errorf("unexpected duration: %s", d)
+	}
+
+	data := []byte{1, 2}
+	af, err = newAudioFrame(data, DefaultAudioFormat, 2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data[0] = 3
+	if af.Samples[0] != 1 {
+		t.Errorf("audio frame samples alias the event data")
+	}
+
+	_, err = newAudioFrame(make([]byte, 9601), DefaultAudioFormat, 2,
+		time.Now())
+	if err == nil {
+		t.Errorf("expected error for partial sample")
+	}
+
+	_, err = newAudioFrame(nil, DefaultAudioFormat, 3, time.Now())
+	if err == nil {
+		t.Errorf("expected error for empty data")
+	}
+}
+
+func TestAudioFormatValid(t *testing.T) {
+	if !DefaultAudioFormat.Valid() {
+		t.Errorf("default audio format is invalid")
+	}
+
+	if (AudioFormat{SampleRate: 48000, Channels: 1, BitsPerSample: 12}).Valid() {
+		t.Errorf("expected 12 bits per sample to be invalid")
+	}
+}
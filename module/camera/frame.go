@@ -0,0 +1,96 @@
+package camera
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Frame is a video frame delivered to a video subscription. Frame data is
+// shared between all subscribers and each of them must release its frame (by
+// calling Release) once it is done with it so the buffer can be reused. Frame
+// data must not be accessed after the frame is released.
+type Frame struct {
+	RGB
+	FrameMetadata
+
+	buf      *frameBuffer
+	released atomic.Bool
+}
+
+// Release releases the frame. When all subscribers released their frames, the
+// shared buffer is returned to the pool. Each subscriber gets its own Frame, so
+// releasing a frame more than once is a no-op and never affects other
+// subscribers or later frames reusing the same buffer.
+func (f *Frame) Release() {
+	if f.released.Swap(true) {
+		return
+	}
+
+	f.buf.release()
+}
+
+// frameBuffer is a pooled, reference counted, frame buffer shared by all
+// frames delivered for a single received frame.
+type frameBuffer struct {
+	RGB
+	FrameMetadata
+
+	refs atomic.Int32
+	pool *framePool
+}
+
+// frame returns a new frame referencing the buffer. Each frame holds one of
+// the references the buffer was obtained with.
+func (b *frameBuffer) frame() *Frame {
+	return &Frame{
+		RGB:           b.RGB,
+		FrameMetadata: b.FrameMetadata,
+		buf:           b,
+	}
+}
+
+func (b *frameBuffer) release() {
+	if b.refs.Add(-1) == 0 {
+		b.pool.put(b)
+	}
+}
+
+// framePool is a pool of frame buffers. Buffers are reused as long as the
+// frame size does not change.
+type framePool struct {
+	p sync.Pool
+}
+
+func newFramePool() *framePool {
+	fp := &framePool{}
+	fp.p.New = func() any {
+		return &frameBuffer{pool: fp}
+	}
+
+	return fp
+}
+
+// get returns a frame buffer holding a copy of the given data with the given
+// format and metadata. The buffer reference count is set to refs and exactly
+// that many frames must be obtained from it.
+func (fp *framePool) get(data []byte, md FrameMetadata,
+	refs int32) *frameBuffer {
+	b := fp.p.Get().(*frameBuffer)
+
+	if cap(b.Pix) < len(data) {
+		b.Pix = make([]uint8, len(data))
+	}
+	b.Pix = b.Pix[:len(data)]
+	copy(b.Pix, data)
+
+	b.Rect = md.Format.Rect()
+	b.Stride = 3 * b.Rect.Dx()
+	b.FrameMetadata = md
+	b.refs.Store(refs)
+
+	return b
+}
+
+func (fp *framePool) put(b *frameBuffer) {
+	fp.p.Put(b)
+}
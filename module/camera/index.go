@@ -0,0 +1,27 @@
+package camera
+
+// Index identifies one of the cameras attached to the robot.
+type Index uint8
+
+const (
+	IndexMain Index = iota
+	IndexSecondary
+	IndexCount
+)
+
+// String returns the camera index as a string.
+func (i Index) String() string {
+	switch i {
+	case IndexMain:
+		return "Main"
+	case IndexSecondary:
+		return "Secondary"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the camera index is valid.
+func (i Index) Valid() bool {
+	return i < IndexCount
+}
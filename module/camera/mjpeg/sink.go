@@ -0,0 +1,263 @@
+// Package mjpeg provides a camera sink that serves the robot camera stream as
+// MJPEG over HTTP.
+package mjpeg
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/module/camera"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
+)
+
+const boundary = "robomasterframe"
+
+// Options are the options for a Sink.
+type Options struct {
+	// Quality is the JPEG quality (1 to 100).
+	Quality int
+
+	// MaxFrameRate is the maximum number of frames per second encoded. A
+	// value of 0 means no limit.
+	MaxFrameRate float64
+}
+
+// DefaultOptions are the options used when none are given.
+var DefaultOptions = Options{
+	Quality:      75,
+	MaxFrameRate: 15,
+}
+
+// Sink encodes frames from a camera module to JPEG and serves them over HTTP.
+// Sink itself is an http.Handler that serves a multipart MJPEG stream. A
+// handler for single snapshots can be obtained with SnapshotHandler.
+type Sink struct {
+	cm *camera.Module
+	l  *logger.Logger
+
+	quality     int
+	minInterval time.Duration
+
+	m       sync.Mutex
+	t       token.Token
+	started bool
+
+	// Only accessed from the video callback.
+	lastEncode time.Time
+	rgba       *image.RGBA
+	buf        bytes.Buffer
+
+	fm       sync.Mutex
+	frame    []byte
+	frameSeq uint64
+	newFrame chan struct{}
+}
+
+var _ http.Handler = (*Sink)(nil)
+
+// New creates a new Sink for the given camera module with the given options.
+// If opts is nil, DefaultOptions is used.
+func New(cm *camera.Module, opts *Options, l *logger.Logger) (*Sink, error) {
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+
+	if opts.Quality < 1 || opts.Quality > 100 {
+		return nil, fmt.Errorf("invalid JPEG quality: %d", opts.Quality)
+	}
+
+	if opts.MaxFrameRate < 0 {
+		return nil, fmt.Errorf("invalid max frame rate: %f", opts.MaxFrameRate)
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	var minInterval time.Duration
+	if opts.MaxFrameRate > 0 {
+		minInterval = time.Duration(float64(time.Second) / opts.MaxFrameRate)
+	}
+
+	return &Sink{
+		cm:          cm,
+		l:           l.WithGroup("mjpeg_sink"),
+		quality:     opts.Quality,
+		minInterval: minInterval,
+		newFrame:    make(chan struct{}),
+	}, nil
+}
+
+// Start starts receiving and encoding frames from the camera module.
+func (s *Sink) Start() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.started {
+		return fmt.Errorf("sink already started")
+	}
+
+	t, err := s.cm.AddVideoCallback(s.onFrame)
+	if err != nil {
+		return err
+	}
+
+	s.t = t
+	s.started = true
+
+	return nil
+}
+
+// Stop stops receiving frames from the camera module. Connected clients stop
+// getting new frames but are not disconnected.
+func (s *Sink) Stop() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if !s.started {
+		return fmt.Errorf("sink not started")
+	}
+
+	s.started = false
+
+	return s.cm.RemoveVideoCallback(s.t)
+}
+
+// ServeHTTP serves the camera stream as a multipart MJPEG stream until the
+// client disconnects.
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type",
+		"multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	var lastSeq uint64
+	for {
+		frame, seq, newFrame := s.latest()
+		if seq == lastSeq {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-newFrame:
+				continue
+			}
+		}
+
+		lastSeq = seq
+
+		err := writePart(w, frame)
+		if err != nil {
+			s.l.Debug("MJPEG client disconnected.", "error", err)
+			return
+		}
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// SnapshotHandler returns an http.Handler that serves the most recent frame as
+// a single JPEG image. It waits for the first frame if none is available yet.
+func (s *Sink) SnapshotHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		frame, seq, newFrame := s.latest()
+		if seq == 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-newFrame:
+				frame, _, _ = s.latest()
+			}
+		}
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", strconv.Itoa(len(frame)))
+		w.Header().Set("Cache-Control", "no-cache")
+
+		_, err := w.Write(frame)
+		if err != nil {
+			s.l.Debug("Error writing snapshot.", "error", err)
+		}
+	})
+}
+
+func (s *Sink) latest() ([]byte, uint64, <-chan struct{}) {
+	s.fm.Lock()
+	defer s.fm.Unlock()
+
+	return s.frame, s.frameSeq, s.newFrame
+}
+
+func (s *Sink) onFrame(frame *camera.RGB, md camera.FrameMetadata) {
+	if s.minInterval > 0 && md.Time.Sub(s.lastEncode) < s.minInterval {
+		return
+	}
+
+	s.lastEncode = md.Time
+
+	// The JPEG encoder has a fast path for RGBA images, so convert the frame
+	// before encoding (and reuse the RGBA buffer between frames).
+	if s.rgba == nil || s.rgba.Rect != frame.Rect {
+		s.rgba = image.NewRGBA(frame.Rect)
+	}
+	frame.CopyToRGBA(s.rgba)
+
+	s.buf.Reset()
+	err := jpeg.Encode(&s.buf, s.rgba, &jpeg.Options{Quality: s.quality})
+	if err != nil {
+		s.l.Error("Error encoding frame.", "error", err)
+		return
+	}
+
+	// Clients might still be writing the previous frame, so we always need a
+	// new slice.
+	encoded := bytes.Clone(s.buf.Bytes())
+
+	s.fm.Lock()
+	s.frame = encoded
+	s.frameSeq++
+	close(s.newFrame)
+	s.newFrame = make(chan struct{})
+	s.fm.Unlock()
+}
+
+func writePart(w http.ResponseWriter, frame []byte) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", "image/jpeg")
+	header.Set("Content-Length", strconv.Itoa(len(frame)))
+
+	_, err := fmt.Fprintf(w, "--%s\r\n", boundary)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range header {
+		_, err = fmt.Fprintf(w, "%s: %s\r\n", k, v[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Write([]byte("\r\n"))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(frame)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte("\r\n"))
+
+	return err
+}
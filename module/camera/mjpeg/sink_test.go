@@ -0,0 +1,134 @@
+package mjpeg
+
+import (
+	"context"
+	"image"
+	"image/jpeg"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/camera"
+)
+
+func newTestFrame() *camera.RGB {
+	rgb := camera.NewRGB(image.Rect(0, 0, 64, 48))
+	for i := range rgb.Pix {
+		rgb.Pix[i] = uint8(i)
+	}
+
+	return rgb
+}
+
+func TestSnapshot(t *testing.T) {
+	s, err := New(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.onFrame(newTestFrame(), camera.FrameMetadata{Sequence: 1,
+		Time: time.Now()})
+
+	rec := httptest.NewRecorder()
+	s.SnapshotHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/snapshot.jpg", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+
+	img, err := jpeg.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("error decoding snapshot: %v", err)
+	}
+
+	if img.Bounds() != image.Rect(0, 0, 64, 48) {
+		t.Errorf("unexpected bounds: %v", img.Bounds())
+	}
+}
+
+func TestStream(t *testing.T) {
+	s, err := New(nil, &Options{Quality: 50}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for i := 0; i < 100 && ctx.Err() == nil; i++ {
+			s.onFrame(newTestFrame(), camera.FrameMetadata{
+				Sequence: uint64(i + 1), Time: time.Now()})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(
+		resp.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mediaType != "multipart/x-mixed-replace" {
+		t.Fatalf("unexpected media type: %s", mediaType)
+	}
+
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	for i := 0; i < 2; i++ {
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("error reading part %d: %v", i, err)
+		}
+
+		_, err = jpeg.Decode(p)
+		if err != nil {
+			t.Fatalf("error decoding part %d: %v", i, err)
+		}
+	}
+}
+
+func TestMaxFrameRate(t *testing.T) {
+	s, err := New(nil, &Options{Quality: 50, MaxFrameRate: 10}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		s.onFrame(newTestFrame(), camera.FrameMetadata{
+			Time: now.Add(time.Duration(i) * 10 * time.Millisecond)})
+	}
+
+	if _, seq, _ := s.latest(); seq != 1 {
+		t.Errorf("unexpected number of encoded frames: %d", seq)
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	if _, err := New(nil, &Options{Quality: 0}, nil); err == nil {
+		t.Errorf("expected error for invalid quality")
+	}
+
+	if _, err := New(nil, &Options{Quality: 50, MaxFrameRate: -1},
+		nil); err == nil {
+		t.Errorf("expected error for invalid max frame rate")
+	}
+}
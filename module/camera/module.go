@@ -1,9 +1,9 @@
 package camera
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"image"
 	"log/slog"
 	"sync"
 	"sync/atomic"
@@ -12,6 +12,7 @@ import (
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/connection"
 	"github.com/brunoga/robomaster/module/internal"
+	"github.com/brunoga/robomaster/support/delivery"
 	"github.com/brunoga/robomaster/support/logger"
 	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
@@ -28,17 +29,39 @@ type Module struct {
 	gntToken token.Token
 	vtsToken token.Token
 	vdrToken token.Token
+	adrToken token.Token
 
 	crToken token.Token
+	vfToken token.Token
 
 	tg *token.Generator
 
+	videoFormat atomic.Pointer[VideoFormat]
+
+	frameSequence   atomic.Uint64
+	lastFrameTime   atomic.Pointer[time.Time]
+	malformedFrames atomic.Uint64
+
+	vst videoStatsTracker
+
+	statsCallbacks *delivery.Callbacks[VideoStats]
+
 	recordingTime atomic.Pointer[time.Duration]
 
 	glTextureData atomic.Pointer[value.GLTextureData]
 
-	m         sync.RWMutex
-	callbacks map[token.Token]VideoCallback
+	fp *framePool
+
+	audioFormat    atomic.Pointer[AudioFormat]
+	audioSequence  atomic.Uint64
+	malformedAudio atomic.Uint64
+
+	m              sync.RWMutex
+	subscriptions  map[token.Token]*VideoSubscription
+	videoCallbacks map[token.Token]*delivery.Worker
+	audioCallbacks *delivery.Callbacks[*AudioFrame]
+
+	photoM sync.Mutex
 }
 
 var _ module.Module = (*Module)(nil)
@@ -54,8 +77,15 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 	l = l.WithGroup("camera_module")
 
 	m := &Module{
-		tg:        token.NewGenerator(),
-		callbacks: make(map[token.Token]VideoCallback),
+		tg:            token.NewGenerator(),
+		fp:            newFramePool(),
+		subscriptions: make(map[token.Token]*VideoSubscription),
+
+		videoCallbacks: make(map[token.Token]*delivery.Worker),
+
+		audioCallbacks: delivery.NewCallbacks[*AudioFrame](),
+
+		statsCallbacks: delivery.NewCallbacks[VideoStats](),
 	}
 
 	m.BaseModule = internal.NewBaseModule(ub, l, "Camera",
@@ -74,11 +104,17 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 
 			if connectedValue.Value {
 				l.Debug("Camera Connected.")
+
+				// Make sure photos and videos stored in the robot get proper
+				// dates.
+				go m.syncDateIfNeeded()
 			} else {
 				l.Debug("Camera Disconnected.")
 			}
 		}, cm)
 
+	m.audioFormat.Store(&DefaultAudioFormat)
+
 	return m, nil
 }
 
@@ -104,55 +140,215 @@ func (m *Module) Start() error {
 		return err
 	}
 
+	m.adrToken, err = m.UB().AddEventTypeListener(event.TypeAudioDataRecv,
+		m.onAudioDataRecv)
+	if err != nil {
+		return err
+	}
+
+	m.vfToken, err = m.UB().AddKeyListener(key.KeyCameraVideoFormat,
+		m.onVideoFormat, true)
+	if err != nil {
+		return err
+	}
+
 	return m.BaseModule.Start()
 }
 
 // AddVideoCallback adds a callback function to be called when a new video frame
 // is received from the robot. The callback function will be called in a
-// separate goroutine. Returns a token that can be used to remove the callback
-// later.
+// separate goroutine with frames queued using the default subscription
+// options. Returns a token that can be used to remove the callback later.
 func (m *Module) AddVideoCallback(vc VideoCallback) (token.Token, error) {
+	return m.AddVideoCallbackWithOptions(vc, nil)
+}
+
+// AddVideoCallbackWithOptions is like AddVideoCallback but allows setting the
+// subscription options used for queueing frames for the callback. If opts is
+// nil, DefaultSubscriptionOptions is used.
+func (m *Module) AddVideoCallbackWithOptions(vc VideoCallback,
+	opts *SubscriptionOptions) (token.Token, error) {
 	if vc == nil {
 		return 0, fmt.Errorf("callback must not be nil")
 	}
 
+	vs, err := m.Subscribe(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	w := delivery.Go(func(w *delivery.Worker) {
+		for f := range vs.Frames() {
+			w.Call(func() {
+				vc(&f.RGB, f.FrameMetadata)
+			})
+			f.Release()
+		}
+	})
+
+	m.m.Lock()
+	m.videoCallbacks[vs.Token()] = w
+	m.m.Unlock()
+
+	return vs.Token(), nil
+}
+
+// RemoveVideoCallback removes the callback function associated with the given
+// token. No new frames are passed to the callback after this returns. If the
+// callback is processing a frame (which is always the case when this is called
+// from the callback itself), this does not wait for it to finish.
+func (m *Module) RemoveVideoCallback(t token.Token) error {
+	m.m.Lock()
+	w := m.videoCallbacks[t]
+	delete(m.videoCallbacks, t)
+	m.m.Unlock()
+
+	err := m.removeSubscription(t)
+
+	if w != nil {
+		// The subscription frames channel is closed now so the callback
+		// goroutine exits on its own.
+		w.Wait()
+	}
+
+	return err
+}
+
+// Subscribe creates a new video subscription with the given options. If opts
+// is nil, DefaultSubscriptionOptions is used. Frames received through the
+// subscription must be released and the subscription must be closed when not
+// needed anymore.
+func (m *Module) Subscribe(opts *SubscriptionOptions) (*VideoSubscription,
+	error) {
 	m.m.Lock()
 	defer m.m.Unlock()
 
-	t := m.tg.Next()
+	vs, err := newVideoSubscription(m, m.tg.Next(), opts)
+	if err != nil {
+		return nil, err
+	}
 
-	m.callbacks[t] = vc
+	m.subscriptions[vs.Token()] = vs
 
-	if len(m.callbacks) == 1 {
-		// We just added the first callback. Start video stream.
+	if m.streamUsersLocked() == 1 {
+		// We just added the first stream user. Start video stream.
 		err := m.UB().SendEvent(event.NewFromType(event.TypeStartVideo))
 		if err != nil {
-			return 0, err
+			delete(m.subscriptions, vs.Token())
+			return nil, err
 		}
 	}
 
+	return vs, nil
+}
+
+// AddAudioCallback adds a callback function to be called when new audio data
+// is received from the robot. Audio is received together with the video
+// stream, so the stream is started if needed. The callback function will be
+// called in a separate goroutine, with audio frames delivered in order. Returns
+// a token that can be used to remove the callback later.
+func (m *Module) AddAudioCallback(ac AudioCallback) (token.Token, error) {
+	if ac == nil {
+		return 0, fmt.Errorf("callback must not be nil")
+	}
+
+	m.m.Lock()
+
+	t := m.audioCallbacks.Add(ac)
+
+	var err error
+	if m.streamUsersLocked() == 1 {
+		// We just added the first stream user. Start video stream.
+		err = m.UB().SendEvent(event.NewFromType(event.TypeStartVideo))
+	}
+
+	m.m.Unlock()
+
+	if err != nil {
+		// The callback might need m, so it must not be held here.
+		m.audioCallbacks.Remove(t)
+		return 0, err
+	}
+
 	return t, nil
 }
 
-// RemoveVideoCallback removes the callback function associated with the given
-// token.
-func (m *Module) RemoveVideoCallback(t token.Token) error {
+// RemoveAudioCallback removes the audio callback associated with the given
+// token, stopping the video stream if nothing else uses it. Audio frames
+// queued for the callback are dropped and it does not get any new ones after
+// this returns.
+func (m *Module) RemoveAudioCallback(t token.Token) error {
+	// The callback might need m, so it must not be held here.
+	if !m.audioCallbacks.Remove(t) {
+		return fmt.Errorf("no audio callback added for token %d", t)
+	}
+
 	m.m.Lock()
 	defer m.m.Unlock()
 
-	_, ok := m.callbacks[t]
-	if !ok {
-		return fmt.Errorf("no callback added for token %d", t)
+	if m.streamUsersLocked() == 0 {
+		// We just removed the last stream user. Stop video stream.
+		return m.stopStream()
 	}
 
-	delete(m.callbacks, t)
+	return nil
+}
 
-	if len(m.callbacks) == 0 {
-		// We just removed the last callback. Stop video stream.
-		err := m.UB().SendEvent(event.NewFromType(event.TypeStopVideo))
-		if err != nil {
-			return err
-		}
+// AudioFormat returns the format assumed for audio data received from the
+// robot.
+func (m *Module) AudioFormat() AudioFormat {
+	return *m.audioFormat.Load()
+}
+
+// SetAudioFormat sets the format assumed for audio data received from the
+// robot.
+func (m *Module) SetAudioFormat(format AudioFormat) error {
+	if !format.Valid() {
+		return fmt.Errorf("invalid audio format: %+v", format)
+	}
+
+	m.audioFormat.Store(&format)
+
+	return nil
+}
+
+// MalformedAudioFrameCount returns the number of audio frames received that
+// did not match the audio format (and were dropped).
+func (m *Module) MalformedAudioFrameCount() uint64 {
+	return m.malformedAudio.Load()
+}
+
+// MalformedFrameCount returns the number of video frames received that could
+// not be matched to any known video format (and were dropped).
+func (m *Module) MalformedFrameCount() uint64 {
+	return m.malformedFrames.Load()
+}
+
+// VideoStats returns the current video stream statistics.
+func (m *Module) VideoStats() VideoStats {
+	return m.vst.snapshot(m.malformedFrames.Load())
+}
+
+// AddVideoStatsCallback adds a callback function to be called whenever video
+// stream statistics are updated (which happens every time the robot reports
+// its video transfer speed). The callback is called in a separate goroutine,
+// with stats delivered in order. Returns a token that can be used to remove the
+// callback later.
+func (m *Module) AddVideoStatsCallback(cb VideoStatsCallback) (token.Token,
+	error) {
+	if cb == nil {
+		return 0, fmt.Errorf("callback must not be nil")
+	}
+
+	return m.statsCallbacks.Add(cb), nil
+}
+
+// RemoveVideoStatsCallback removes the video stats callback associated with
+// the given token. Stats updates still pending for it are dropped and it is
+// not called again after this returns.
+func (m *Module) RemoveVideoStatsCallback(t token.Token) error {
+	if !m.statsCallbacks.Remove(t) {
+		return fmt.Errorf("no video stats callback added for token %d", t)
 	}
 
 	return nil
@@ -181,6 +377,10 @@ func (m *Module) SetVideoFormat(format VideoFormat) error {
 
 // SetVideoQuality sets the video quality.
 func (m *Module) SetVideoQuality(quality VideoQuality) error {
+	if !quality.Valid() {
+		return fmt.Errorf("invalid video quality: %d", quality)
+	}
+
 	return m.UB().SetKeyValueSync(key.KeyCameraVideoTransRate, &value.Float64{Value: float64(quality)})
 }
 
@@ -302,20 +502,44 @@ func (c *Module) GLTextureData() (value.GLTextureData, error) {
 
 // Stop stops the camera manager.
 func (m *Module) Stop() error {
+	m.m.RLock()
+	for _, vs := range m.subscriptions {
+		vs.shutdown()
+	}
+	m.m.RUnlock()
+
 	m.m.Lock()
 
-	if len(m.callbacks) > 0 {
-		m.callbacks = make(map[token.Token]VideoCallback)
+	streamUsers := m.streamUsersLocked()
+
+	subscriptions := m.subscriptions
+	m.subscriptions = make(map[token.Token]*VideoSubscription)
 
-		err := m.UB().SendEvent(event.NewFromType(event.TypeStopVideo))
+	videoCallbacks := m.videoCallbacks
+	m.videoCallbacks = make(map[token.Token]*delivery.Worker)
+
+	m.m.Unlock()
+
+	for _, vs := range subscriptions {
+		vs.drain()
+	}
+
+	// Video callback goroutines exit on their own now that their
+	// subscriptions are drained.
+	for _, w := range videoCallbacks {
+		w.Wait()
+	}
+
+	m.audioCallbacks.Clear()
+	m.statsCallbacks.Clear()
+
+	if streamUsers > 0 {
+		err := m.stopStream()
 		if err != nil {
-			m.m.Unlock()
 			return err
 		}
 	}
 
-	m.m.Unlock()
-
 	err := m.UB().RemoveEventTypeListener(event.TypeGetNativeTexture,
 		m.gntToken)
 	if err != nil {
@@ -333,6 +557,16 @@ func (m *Module) Stop() error {
 		return err
 	}
 
+	err = m.UB().RemoveEventTypeListener(event.TypeAudioDataRecv, m.adrToken)
+	if err != nil {
+		return err
+	}
+
+	err = m.UB().RemoveKeyListener(key.KeyCameraVideoFormat, m.vfToken)
+	if err != nil {
+		return err
+	}
+
 	return m.BaseModule.Stop()
 }
 
@@ -351,17 +585,141 @@ func (m *Module) onGetNativeTexture(e *event.Event, data []byte, dataType event.
 }
 
 func (m *Module) onVideoTransferSpeed(e *event.Event, data []byte, dataType event.DataType) {
-	m.Logger().Debug("onVideoTransferSpeed", "data", data, "dataType", dataType)
+	if dataType != event.DataTypeUint64 || len(data) != 8 {
+		m.Logger().Error("Video transfer speed: Unexpected data.", "data", data,
+			"dataType", dataType)
+		return
+	}
+
+	m.vst.onTransferSpeed(time.Now(), binary.LittleEndian.Uint64(data))
+
+	stats := m.VideoStats()
+
+	m.statsCallbacks.Push(stats)
+}
+
+func (m *Module) onVideoFormat(r *result.Result) {
+	if !r.Succeeded() {
+		m.Logger().Error("Video format: Unsuccessfull result.", "result", r)
+		return
+	}
+
+	formatValue, ok := r.Value().(*value.Uint64)
+	if !ok {
+		m.Logger().Error("Video format: Unexpected value.", "value", r.Value())
+		return
+	}
+
+	format := VideoFormat(formatValue.Value)
+	m.videoFormat.Store(&format)
 }
 
 func (m *Module) onVideoDataRecv(e *event.Event, data []byte, dataType event.DataType) {
-	rgb := NewRGBFromBytes(data, image.Rect(0, 0, 1280, 720))
+	md := FrameMetadata{
+		Sequence: m.frameSequence.Add(1),
+		Time:     time.Now(),
+	}
+
+	// Default to 720p, which is what the robot streams unless told
+	// otherwise.
+	preferred := VideoFormat720p_30
+	if vf := m.videoFormat.Load(); vf != nil {
+		preferred = *vf
+	}
+
+	// The streamed frame size does not necessarily follow the configured
+	// video format, so we derive the actual format from the frame size.
+	format, ok := videoFormatForFrameSize(len(data), preferred)
+	if !ok {
+		m.malformedFrames.Add(1)
+		m.Logger().Warn("Dropping malformed video frame.", "sequence",
+			md.Sequence, "size", len(data))
+		return
+	}
+
+	md.Format = format
+
+	m.checkStreamStall(md.Time)
+
+	m.vst.onFrame(md.Time, format.FrameRate())
 
 	m.m.RLock()
+	defer m.m.RUnlock()
+
+	if len(m.subscriptions) == 0 {
+		return
+	}
+
+	// The frame is shared between all subscribers and returned to the pool
+	// once all of them release it.
+	b := m.fp.get(data, md, int32(len(m.subscriptions)))
 
-	for _, vc := range m.callbacks {
-		go vc(rgb)
+	for _, vs := range m.subscriptions {
+		vs.deliver(b.frame())
 	}
+}
 
+func (m *Module) removeSubscription(t token.Token) error {
+	m.m.RLock()
+	vs, ok := m.subscriptions[t]
 	m.m.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no subscription for token %d", t)
+	}
+
+	// Unblock any pending deliveries so we can get the write lock.
+	vs.shutdown()
+
+	m.m.Lock()
+
+	if _, ok := m.subscriptions[t]; !ok {
+		// Concurrently removed.
+		m.m.Unlock()
+		return nil
+	}
+
+	delete(m.subscriptions, t)
+
+	var err error
+	if m.streamUsersLocked() == 0 {
+		// We just removed the last stream user. Stop video stream.
+		err = m.stopStream()
+	}
+
+	m.m.Unlock()
+
+	// No deliveries can be in progress anymore.
+	vs.drain()
+
+	return err
+}
+
+func (m *Module) onAudioDataRecv(e *event.Event, data []byte, dataType event.DataType) {
+	sequence := m.audioSequence.Add(1)
+
+	af, err := newAudioFrame(data, m.AudioFormat(), sequence, time.Now())
+	if err != nil {
+		m.malformedAudio.Add(1)
+		m.Logger().Warn("Dropping malformed audio frame.", "sequence",
+			sequence, "error", err)
+		return
+	}
+
+	m.audioCallbacks.Push(af)
+}
+
+// stopStream stops the video stream.
+func (m *Module) stopStream() error {
+	// Frames received after the stream is started again do not indicate a
+	// stall.
+	m.lastFrameTime.Store(nil)
+
+	return m.UB().SendEvent(event.NewFromType(event.TypeStopVideo))
+}
+
+// streamUsersLocked returns the number of users of the video stream (which
+// also carries audio). Must be called with m held.
+func (m *Module) streamUsersLocked() int {
+	return len(m.subscriptions) + m.audioCallbacks.Len()
 }
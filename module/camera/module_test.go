@@ -0,0 +1,68 @@
+package camera
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/mock"
+)
+
+// sentValues returns all values set for the given key, in order.
+func sentValues(uw *mock.UnityBridge, k *key.Key) []string {
+	code := event.NewFromTypeAndSubType(event.TypeSetValue, k.SubType()).Code()
+
+	var vs []string
+	for _, e := range uw.Sent() {
+		if e.Code == code {
+			vs = append(vs, e.String)
+		}
+	}
+
+	return vs
+}
+
+func TestSetVideoQuality(t *testing.T) {
+	uw, ub := mock.NewStartedUnityBridge(t)
+
+	m, err := New(ub, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qualities := []VideoQuality{
+		VideoQualityNormal,
+		VideoQualityGood,
+		VideoQualityBest,
+	}
+
+	for _, quality := range qualities {
+		err := m.SetVideoQuality(quality)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if m.SetVideoQuality(VideoQualityCount) == nil {
+		t.Error("expected error setting invalid video quality")
+	}
+
+	vs := sentValues(uw, key.KeyCameraVideoTransRate)
+	if len(vs) != len(qualities) {
+		t.Fatalf("got %d values sent, want %d", len(vs), len(qualities))
+	}
+
+	for i, quality := range qualities {
+		var v value.Float64
+		err := json.Unmarshal([]byte(vs[i]), &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v.Value != float64(quality) {
+			t.Errorf("%s: got %v, want %v", quality, v.Value, float64(quality))
+		}
+	}
+}
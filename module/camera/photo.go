@@ -0,0 +1,223 @@
+package camera
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+const (
+	// photoStartTimeout is how long we wait for the camera to report that it
+	// started shooting a photo.
+	photoStartTimeout = 2 * time.Second
+
+	// photoTimeout is the maximum time we wait for a single photo to be
+	// taken.
+	photoTimeout = 10 * time.Second
+)
+
+// PhotoSize returns the currently set photo size.
+func (m *Module) PhotoSize() (PhotoSize, error) {
+	r, err := m.UB().GetKeyValueSync(key.KeyCameraPhotoSize, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return PhotoSize(r.Value().(*value.Uint64).Value), nil
+}
+
+// SetPhotoSize sets the size of photos taken by the camera.
+func (m *Module) SetPhotoSize(size PhotoSize) error {
+	if !size.Valid() {
+		return fmt.Errorf("invalid photo size: %d", size)
+	}
+
+	return m.UB().SetKeyValueSync(key.KeyCameraPhotoSize,
+		&value.Uint64{Value: uint64(size)})
+}
+
+// IsShootingPhoto returns whether the camera is currently taking a photo.
+func (m *Module) IsShootingPhoto() (bool, error) {
+	r, err := m.UB().GetKeyValueSync(key.KeyCameraIsShootingPhoto, false)
+	if err != nil {
+		return false, err
+	}
+
+	return r.Value().(*value.Bool).Value, nil
+}
+
+// TakePhoto takes a single photo and stores it in the robot's SD card. The
+// camera is switched to photo mode if needed. Blocks until the photo is taken.
+func (m *Module) TakePhoto() error {
+	m.photoM.Lock()
+	defer m.photoM.Unlock()
+
+	err := m.preparePhotoMode()
+	if err != nil {
+		return err
+	}
+
+	return m.takePhotoLocked()
+}
+
+// TakePhotos takes count photos, waiting for the given interval between the
+// start of consecutive shots. An interval of 0 takes the photos as fast as
+// possible (burst). A count of 0 keeps taking photos until the given context
+// is canceled (time-lapse). Returns the number of photos actually taken. The
+// camera is switched to photo mode if needed and all photos are stored in the
+// robot's SD card.
+func (m *Module) TakePhotos(ctx context.Context, count int,
+	interval time.Duration) (int, error) {
+	if count < 0 {
+		return 0, fmt.Errorf("invalid photo count: %d", count)
+	}
+
+	if interval < 0 {
+		return 0, fmt.Errorf("invalid photo interval: %s", interval)
+	}
+
+	m.photoM.Lock()
+	defer m.photoM.Unlock()
+
+	err := m.preparePhotoMode()
+	if err != nil {
+		return 0, err
+	}
+
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+	}
+
+	taken := 0
+	for count == 0 || taken < count {
+		if err := ctx.Err(); err != nil {
+			return taken, err
+		}
+
+		err = m.takePhotoLocked()
+		if err != nil {
+			return taken, err
+		}
+
+		taken++
+
+		if ticker == nil || (count != 0 && taken == count) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return taken, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return taken, nil
+}
+
+func (m *Module) preparePhotoMode() error {
+	recording, err := m.IsRecordingVideo()
+	if err != nil {
+		return err
+	}
+
+	if recording {
+		return fmt.Errorf("can not take photos while recording video")
+	}
+
+	currentMode, err := m.Mode()
+	if err != nil {
+		return err
+	}
+
+	if currentMode != ModePhoto {
+		err = m.SetMode(ModePhoto)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takePhotoLocked takes a single photo and waits for it to complete. Must be
+// called with photoM held and the camera in photo mode.
+func (m *Module) takePhotoLocked() error {
+	shootingChan := make(chan bool, 1)
+
+	t, err := m.UB().AddKeyListener(key.KeyCameraIsShootingPhoto,
+		func(r *result.Result) {
+			if !r.Succeeded() {
+				return
+			}
+
+			shooting, ok := r.Value().(*value.Bool)
+			if !ok {
+				return
+			}
+
+			// Only the most recent state matters.
+			select {
+			case <-shootingChan:
+			default:
+			}
+			shootingChan <- shooting.Value
+		}, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := m.UB().RemoveKeyListener(key.KeyCameraIsShootingPhoto,
+			t); err != nil {
+			m.Logger().Error("Error removing photo shooting listener.",
+				"error", err)
+		}
+	}()
+
+	err = m.UB().PerformActionForKeySync(key.KeyCameraStartShootPhoto, nil)
+	if err != nil {
+		return err
+	}
+
+	started := false
+	startTimer := time.NewTimer(photoStartTimeout)
+	defer startTimer.Stop()
+	timeoutTimer := time.NewTimer(photoTimeout)
+	defer timeoutTimer.Stop()
+
+	for {
+		select {
+		case shooting := <-shootingChan:
+			if shooting {
+				started = true
+			} else if started {
+				return nil
+			}
+		case <-startTimer.C:
+			if started {
+				continue
+			}
+
+			// We might have missed the whole shooting cycle. Check the
+			// current state directly.
+			shooting, err := m.IsShootingPhoto()
+			if err != nil {
+				return err
+			}
+
+			if !shooting {
+				return nil
+			}
+
+			started = true
+		case <-timeoutTimer.C:
+			return fmt.Errorf("timeout waiting for photo to be taken")
+		}
+	}
+}
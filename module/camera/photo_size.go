@@ -0,0 +1,34 @@
+package camera
+
+// PhotoSize is the size (aspect ratio) of photos taken by the camera.
+//
+// Values follow the usual DJI photo aspect ratio enumeration. This was not
+// verified against the robot and the actual resolution used for each of them
+// is unknown.
+type PhotoSize uint8
+
+const (
+	PhotoSize4_3 PhotoSize = iota
+	PhotoSize16_9
+	PhotoSize3_2
+	PhotoSizeCount
+)
+
+// String returns the photo size as a string.
+func (ps PhotoSize) String() string {
+	switch ps {
+	case PhotoSize4_3:
+		return "4:3"
+	case PhotoSize16_9:
+		return "16:9"
+	case PhotoSize3_2:
+		return "3:2"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the photo size is valid.
+func (ps PhotoSize) Valid() bool {
+	return ps < PhotoSizeCount
+}
@@ -0,0 +1,350 @@
+package camera
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/module/connection"
+	"github.com/brunoga/robomaster/support/logger"
+)
+
+// QualityDecision describes a video quality change decided by a
+// QualityController.
+type QualityDecision struct {
+	// Time is the time the decision was made.
+	Time time.Time
+
+	// SignalQualityLevel is the signal quality level at decision time.
+	SignalQualityLevel uint8
+
+	// FrameRate is the measured frame rate at decision time (0 if unknown).
+	FrameRate float64
+
+	// Current is the video quality in use when the decision was made.
+	Current VideoQuality
+
+	// Proposed is the video quality the controller wants to switch to.
+	Proposed VideoQuality
+}
+
+// QualityControllerOptions are the options for a QualityController.
+type QualityControllerOptions struct {
+	// Interval is how often link conditions are evaluated.
+	Interval time.Duration
+
+	// DowngradeSignalLevel is the signal quality level at or below which the
+	// link is considered bad.
+	DowngradeSignalLevel uint8
+
+	// UpgradeSignalLevel is the signal quality level at or above which the
+	// link is considered good. Levels in between the downgrade and upgrade
+	// levels keep the current quality (hysteresis).
+	UpgradeSignalLevel uint8
+
+	// MinFrameRateRatio is the ratio of the nominal frame rate under which
+	// the link is considered bad.
+	MinFrameRateRatio float64
+
+	// DowngradeHoldTime is how long the link must be bad before the quality is
+	// stepped down.
+	DowngradeHoldTime time.Duration
+
+	// UpgradeHoldTime is how long the link must be good before the quality is
+	// stepped up.
+	UpgradeHoldTime time.Duration
+
+	// MinQuality and MaxQuality are the quality limits.
+	MinQuality VideoQuality
+	MaxQuality VideoQuality
+
+	// InitialQuality is the quality set when the controller starts.
+	InitialQuality VideoQuality
+
+	// Override, if non-nil, is called for every decision and returns the
+	// quality that will actually be set. Returning d.Current vetoes the
+	// change.
+	Override func(d QualityDecision) VideoQuality
+
+	// OnDecision, if non-nil, is called after a decision was applied with the
+	// quality that was actually set.
+	OnDecision func(d QualityDecision, applied VideoQuality)
+}
+
+// DefaultQualityControllerOptions are the options used when none are given.
+var DefaultQualityControllerOptions = QualityControllerOptions{
+	Interval:             500 * time.Millisecond,
+	DowngradeSignalLevel: 20,
+	UpgradeSignalLevel:   35,
+	MinFrameRateRatio:    0.8,
+	DowngradeHoldTime:    1 * time.Second,
+	UpgradeHoldTime:      5 * time.Second,
+	MinQuality:           VideoQualityNormal,
+	MaxQuality:           VideoQualityBest,
+	InitialQuality:       VideoQualityBest,
+}
+
+// QualityController adapts the video quality to the current link quality. It
+// steps the quality down quickly when the signal is weak or frames are being
+// lost (to keep control latency acceptable) and steps it back up slowly when
+// the link recovers.
+type QualityController struct {
+	m  *Module
+	cm *connection.Connection
+	l  *logger.Logger
+
+	opts QualityControllerOptions
+
+	mu      sync.Mutex
+	quality VideoQuality
+	state   qualityState
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// qualityState keeps track of how long the link has been good or bad.
+type qualityState struct {
+	badSince  time.Time
+	goodSince time.Time
+}
+
+// NewQualityController creates a new QualityController for the given camera
+// module and connection with the given options. If opts is nil,
+// DefaultQualityControllerOptions is used.
+func NewQualityController(m *Module, cm *connection.Connection,
+	opts *QualityControllerOptions, l *logger.Logger) (*QualityController,
+	error) {
+	if opts == nil {
+		opts = &DefaultQualityControllerOptions
+	}
+
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	return &QualityController{
+		m:       m,
+		cm:      cm,
+		l:       l.WithGroup("video_quality_controller"),
+		opts:    *opts,
+		quality: opts.InitialQuality,
+	}, nil
+}
+
+// Start sets the initial video quality and starts adapting it.
+func (qc *QualityController) Start() error {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	if qc.quit != nil {
+		return fmt.Errorf("quality controller already started")
+	}
+
+	err := qc.m.SetVideoQuality(qc.opts.InitialQuality)
+	if err != nil {
+		return err
+	}
+
+	qc.quality = qc.opts.InitialQuality
+	qc.state = qualityState{}
+	qc.quit = make(chan struct{})
+	qc.done = make(chan struct{})
+
+	go qc.loop(qc.quit, qc.done)
+
+	return nil
+}
+
+// Stop stops adapting the video quality. The current quality is kept.
+func (qc *QualityController) Stop() error {
+	qc.mu.Lock()
+
+	if qc.quit == nil {
+		qc.mu.Unlock()
+		return fmt.Errorf("quality controller not started")
+	}
+
+	quit, done := qc.quit, qc.done
+	qc.quit, qc.done = nil, nil
+
+	qc.mu.Unlock()
+
+	close(quit)
+	<-done
+
+	return nil
+}
+
+// Quality returns the video quality currently set by the controller.
+func (qc *QualityController) Quality() VideoQuality {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	return qc.quality
+}
+
+func (qc *QualityController) loop(quit <-chan struct{},
+	done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(qc.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case now := <-ticker.C:
+			qc.evaluate(now)
+		}
+	}
+}
+
+func (qc *QualityController) evaluate(now time.Time) {
+	signalLevel := qc.cm.SignalQualityLevel()
+
+	// Only consider the frame rate if the stream is active. The robot keeps
+	// reporting its transfer speed even if frames stop arriving, so a stream
+	// with no recent frames is considered stalled (0 fps).
+	var frameRate, nominalFrameRate float64
+	stats := qc.m.VideoStats()
+	if now.Sub(stats.Time) < 2*qc.opts.Interval {
+		if now.Sub(stats.LastFrameTime) < 2*qc.opts.Interval {
+			frameRate = stats.FrameRate
+		}
+
+		nominalFrameRate = VideoFormat720p_30.FrameRate()
+		if vf := qc.m.videoFormat.Load(); vf != nil && vf.Valid() {
+			nominalFrameRate = vf.FrameRate()
+		}
+	}
+
+	qc.mu.Lock()
+	current := qc.quality
+	proposed := qc.opts.decide(&qc.state, current, signalLevel, frameRate,
+		nominalFrameRate, now)
+	qc.mu.Unlock()
+
+	if proposed == current {
+		return
+	}
+
+	d := QualityDecision{
+		Time:               now,
+		SignalQualityLevel: signalLevel,
+		FrameRate:          frameRate,
+		Current:            current,
+		Proposed:           proposed,
+	}
+
+	applied := proposed
+	if qc.opts.Override != nil {
+		applied = qc.opts.Override(d)
+	}
+
+	if applied != current && applied.Valid() {
+		err := qc.m.SetVideoQuality(applied)
+		if err != nil {
+			qc.l.Error("Error setting video quality.", "quality", applied,
+				"error", err)
+			return
+		}
+
+		qc.l.Debug("Video quality changed.", "from", current, "to", applied,
+			"signal_level", signalLevel, "frame_rate", frameRate)
+
+		qc.mu.Lock()
+		qc.quality = applied
+		qc.mu.Unlock()
+	} else {
+		applied = current
+	}
+
+	if qc.opts.OnDecision != nil {
+		qc.opts.OnDecision(d, applied)
+	}
+}
+
+// decide returns the quality that should be used given the current link
+// conditions, updating the given state. The frame rate is only considered if
+// nominalFrameRate is not 0.
+func (o *QualityControllerOptions) decide(s *qualityState,
+	current VideoQuality, signalLevel uint8, frameRate,
+	nominalFrameRate float64, now time.Time) VideoQuality {
+	lowFrameRate := nominalFrameRate > 0 &&
+		frameRate < o.MinFrameRateRatio*nominalFrameRate
+
+	bad := signalLevel <= o.DowngradeSignalLevel || lowFrameRate
+	good := signalLevel >= o.UpgradeSignalLevel && !lowFrameRate
+
+	switch {
+	case bad:
+		s.goodSince = time.Time{}
+		if s.badSince.IsZero() {
+			s.badSince = now
+		}
+
+		if now.Sub(s.badSince) >= o.DowngradeHoldTime &&
+			current > o.MinQuality {
+			s.badSince = time.Time{}
+			return current - 1
+		}
+	case good:
+		s.badSince = time.Time{}
+		if s.goodSince.IsZero() {
+			s.goodSince = now
+		}
+
+		if now.Sub(s.goodSince) >= o.UpgradeHoldTime &&
+			current < o.MaxQuality {
+			s.goodSince = time.Time{}
+			return current + 1
+		}
+	default:
+		// Inside the hysteresis band. Keep current quality.
+		s.badSince = time.Time{}
+		s.goodSince = time.Time{}
+	}
+
+	return current
+}
+
+func (o *QualityControllerOptions) validate() error {
+	if o.Interval <= 0 {
+		return fmt.Errorf("invalid interval: %s", o.Interval)
+	}
+
+	if o.DowngradeSignalLevel >= o.UpgradeSignalLevel {
+		return fmt.Errorf("downgrade signal level (%d) must be lower than "+
+			"upgrade signal level (%d)", o.DowngradeSignalLevel,
+			o.UpgradeSignalLevel)
+	}
+
+	if o.MinFrameRateRatio < 0 || o.MinFrameRateRatio > 1 {
+		return fmt.Errorf("invalid min frame rate ratio: %f",
+			o.MinFrameRateRatio)
+	}
+
+	if o.DowngradeHoldTime < 0 || o.UpgradeHoldTime < 0 {
+		return fmt.Errorf("invalid hold times")
+	}
+
+	if !o.MinQuality.Valid() || !o.MaxQuality.Valid() ||
+		o.MinQuality > o.MaxQuality {
+		return fmt.Errorf("invalid quality limits: %s to %s", o.MinQuality,
+			o.MaxQuality)
+	}
+
+	if o.InitialQuality < o.MinQuality || o.InitialQuality > o.MaxQuality {
+		return fmt.Errorf("initial quality %s outside limits",
+			o.InitialQuality)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package camera
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQualityDecideDowngradeAndUpgrade(t *testing.T) {
+	o := DefaultQualityControllerOptions
+	var s qualityState
+
+	now := time.Unix(1000, 0)
+	q := VideoQualityBest
+
+	// Weak signal, but not for long enough.
+	q = o.decide(&s, q, 10, 0, 0, now)
+	if q != VideoQualityBest {
+		t.Fatalf("downgraded too early: %s", q)
+	}
+
+	now = now.Add(o.DowngradeHoldTime)
+	q = o.decide(&s, q, 10, 0, 0, now)
+	if q != VideoQualityGood {
+		t.Fatalf("expected downgrade to Good, got %s", q)
+	}
+
+	// Hysteresis band keeps the current quality.
+	for i := 0; i < 20; i++ {
+		now = now.Add(time.Second)
+		q = o.decide(&s, q, 30, 0, 0, now)
+	}
+	if q != VideoQualityGood {
+		t.Fatalf("quality changed inside hysteresis band: %s", q)
+	}
+
+	// Good signal must hold for the upgrade hold time.
+	q = o.decide(&s, q, 50, 0, 0, now)
+	now = now.Add(o.UpgradeHoldTime - time.Millisecond)
+	q = o.decide(&s, q, 50, 0, 0, now)
+	if q != VideoQualityGood {
+		t.Fatalf("upgraded too early: %s", q)
+	}
+
+	now = now.Add(time.Millisecond)
+	q = o.decide(&s, q, 50, 0, 0, now)
+	if q != VideoQualityBest {
+		t.Fatalf("expected upgrade to Best, got %s", q)
+	}
+
+	// Already at max quality.
+	now = now.Add(o.UpgradeHoldTime)
+	q = o.decide(&s, q, 50, 0, 0, now)
+	if q != VideoQualityBest {
+		t.Fatalf("unexpected quality: %s", q)
+	}
+}
+
+func TestQualityDecideLowFrameRate(t *testing.T) {
+	o := DefaultQualityControllerOptions
+	var s qualityState
+
+	now := time.Unix(1000, 0)
+	q := VideoQualityGood
+
+	// Strong signal but frames are being lost.
+	q = o.decide(&s, q, 60, 15, 30, now)
+	now = now.Add(o.DowngradeHoldTime)
+	q = o.decide(&s, q, 60, 15, 30, now)
+	if q != VideoQualityNormal {
+		t.Fatalf("expected downgrade to Normal, got %s", q)
+	}
+
+	// Never go below the minimum.
+	now = now.Add(10 * o.DowngradeHoldTime)
+	q = o.decide(&s, q, 60, 15, 30, now)
+	if q != VideoQualityNormal {
+		t.Fatalf("unexpected quality: %s", q)
+	}
+}
+
+func TestQualityDecideStalledStream(t *testing.T) {
+	o := DefaultQualityControllerOptions
+	var s qualityState
+
+	now := time.Unix(1000, 0)
+	q := VideoQualityGood
+
+	// Strong signal but no frames are arriving.
+	q = o.decide(&s, q, 60, 0, 30, now)
+	now = now.Add(o.DowngradeHoldTime)
+	q = o.decide(&s, q, 60, 0, 30, now)
+	if q != VideoQualityNormal {
+		t.Fatalf("expected downgrade to Normal, got %s", q)
+	}
+}
+
+func TestQualityControllerOptionsValidate(t *testing.T) {
+	if err := DefaultQualityControllerOptions.validate(); err != nil {
+		t.Fatalf("default options are invalid: %v", err)
+	}
+
+	o := DefaultQualityControllerOptions
+	o.DowngradeSignalLevel = o.UpgradeSignalLevel
+	if err := o.validate(); err == nil {
+		t.Errorf("expected error for empty hysteresis band")
+	}
+
+	o = DefaultQualityControllerOptions
+	o.InitialQuality = VideoQualityNormal
+	o.MinQuality = VideoQualityGood
+	if err := o.validate(); err == nil {
+		t.Errorf("expected error for initial quality outside limits")
+	}
+}
@@ -0,0 +1,266 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"time"
+
+	"github.com/brunoga/robomaster/module/camera"
+)
+
+const (
+	aviHeaderSize = 224
+
+	aviFlagHasIndex = 0x10
+	aviFlagKeyFrame = 0x10
+)
+
+// aviWriter writes MJPEG frames to an AVI file.
+type aviWriter struct {
+	f       *os.File
+	quality int
+
+	rect      image.Rectangle
+	frameRate float64
+
+	rgba *image.RGBA
+	buf  bytes.Buffer
+
+	// Index entries (offset, size) relative to the movi list.
+	index    [][2]uint32
+	maxFrame uint32
+	moviSize uint32
+
+	firstTime time.Time
+	lastTime  time.Time
+}
+
+func newAVIWriter(path string, rect image.Rectangle, frameRate float64,
+	quality int) (*aviWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &aviWriter{
+		f:         f,
+		quality:   quality,
+		rect:      rect,
+		frameRate: frameRate,
+		rgba:      image.NewRGBA(rect),
+		moviSize:  4, // "movi" fourcc.
+	}
+
+	// Write a placeholder header. It is rewritten with the final values when
+	// the file is closed.
+	_, err = f.Write(w.header(0, frameRate))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *aviWriter) WriteFrame(frame *camera.RGB,
+	md camera.FrameMetadata) error {
+	frame.CopyToRGBA(w.rgba)
+
+	w.buf.Reset()
+	err := jpeg.Encode(&w.buf, w.rgba, &jpeg.Options{Quality: w.quality})
+	if err != nil {
+		return err
+	}
+
+	data, err := addJPEGComment(w.buf.Bytes(), timestampText(md))
+	if err != nil {
+		return err
+	}
+
+	size := uint32(len(data))
+
+	var chunkHeader [8]byte
+	copy(chunkHeader[:], "00dc")
+	binary.LittleEndian.PutUint32(chunkHeader[4:], size)
+
+	_, err = w.f.Write(chunkHeader[:])
+	if err != nil {
+		return err
+	}
+
+	_, err = w.f.Write(data)
+	if err != nil {
+		return err
+	}
+
+	if size%2 != 0 {
+		_, err = w.f.Write([]byte{0})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.index = append(w.index, [2]uint32{w.moviSize, size})
+	w.moviSize += 8 + size + size%2
+	w.maxFrame = max(w.maxFrame, size)
+
+	if w.firstTime.IsZero() {
+		w.firstTime = md.Time
+	}
+	w.lastTime = md.Time
+
+	return nil
+}
+
+func (w *aviWriter) Size() int64 {
+	return int64(aviHeaderSize+w.moviSize-4) + 8 + int64(16*len(w.index))
+}
+
+func (w *aviWriter) Close() error {
+	err := w.finish()
+	if err != nil {
+		w.f.Close()
+		return err
+	}
+
+	return w.f.Close()
+}
+
+func (w *aviWriter) finish() error {
+	// Write the index.
+	idx := make([]byte, 8+16*len(w.index))
+	copy(idx, "idx1")
+	binary.LittleEndian.PutUint32(idx[4:], uint32(16*len(w.index)))
+	for i, entry := range w.index {
+		e := idx[8+16*i:]
+		copy(e, "00dc")
+		binary.LittleEndian.PutUint32(e[4:], aviFlagKeyFrame)
+		binary.LittleEndian.PutUint32(e[8:], entry[0])
+		binary.LittleEndian.PutUint32(e[12:], entry[1])
+	}
+
+	_, err := w.f.Write(idx)
+	if err != nil {
+		return err
+	}
+
+	// Use the actual frame rate if we can measure it.
+	frameRate := w.frameRate
+	if len(w.index) > 1 {
+		elapsed := w.lastTime.Sub(w.firstTime).Seconds()
+		if elapsed > 0 {
+			frameRate = float64(len(w.index)-1) / elapsed
+		}
+	}
+
+	_, err = w.f.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.f.Write(w.header(uint32(len(w.index)), frameRate))
+
+	return err
+}
+
+// header returns the AVI header (up to and including the movi list header)
+// for the given frame count and frame rate.
+func (w *aviWriter) header(frames uint32, frameRate float64) []byte {
+	width := uint32(w.rect.Dx())
+	height := uint32(w.rect.Dy())
+	usPerFrame := uint32(1000000 / frameRate)
+
+	var b bytes.Buffer
+	le := func(v any) {
+		_ = binary.Write(&b, binary.LittleEndian, v)
+	}
+
+	riffSize := uint32(aviHeaderSize-8) + w.moviSize - 4 + 8 +
+		uint32(16*len(w.index))
+
+	b.WriteString("RIFF")
+	le(riffSize)
+	b.WriteString("AVI ")
+
+	b.WriteString("LIST")
+	le(uint32(192))
+	b.WriteString("hdrl")
+
+	b.WriteString("avih")
+	le(uint32(56))
+	le(usPerFrame)
+	le(uint32(0)) // Max bytes per second.
+	le(uint32(0)) // Padding granularity.
+	le(uint32(aviFlagHasIndex))
+	le(frames)
+	le(uint32(0)) // Initial frames.
+	le(uint32(1)) // Streams.
+	le(w.maxFrame)
+	le(width)
+	le(height)
+	le([4]uint32{})
+
+	b.WriteString("LIST")
+	le(uint32(116))
+	b.WriteString("strl")
+
+	b.WriteString("strh")
+	le(uint32(56))
+	b.WriteString("vids")
+	b.WriteString("MJPG")
+	le(uint32(0)) // Flags.
+	le(uint16(0)) // Priority.
+	le(uint16(0)) // Language.
+	le(uint32(0)) // Initial frames.
+	le(usPerFrame)
+	le(uint32(1000000))
+	le(uint32(0)) // Start.
+	le(frames)
+	le(w.maxFrame)
+	le(int32(-1)) // Quality.
+	le(uint32(0)) // Sample size.
+	le([4]int16{0, 0, int16(width), int16(height)})
+
+	b.WriteString("strf")
+	le(uint32(40))
+	le(uint32(40))
+	le(int32(width))
+	le(int32(height))
+	le(uint16(1))  // Planes.
+	le(uint16(24)) // Bit count.
+	b.WriteString("MJPG")
+	le(width * height * 3)
+	le([4]uint32{})
+
+	b.WriteString("LIST")
+	le(w.moviSize)
+	b.WriteString("movi")
+
+	return b.Bytes()
+}
+
+// addJPEGComment returns the given JPEG data with a comment segment containing
+// the given text inserted right after the SOI marker.
+func addJPEGComment(data []byte, text string) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, fmt.Errorf("invalid JPEG data")
+	}
+
+	if len(text)+2 > 0xffff {
+		return nil, fmt.Errorf("JPEG comment too long")
+	}
+
+	out := make([]byte, 0, len(data)+4+len(text))
+	out = append(out, data[:2]...)
+	out = append(out, 0xff, 0xfe)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(text)+2))
+	out = append(out, text...)
+	out = append(out, data[2:]...)
+
+	return out, nil
+}
@@ -0,0 +1,52 @@
+package recorder
+
+// Format is the local container format used for recordings.
+type Format uint8
+
+const (
+	// FormatMJPEGAVI records frames as JPEG images inside an AVI container.
+	// Frame timestamps are embedded as JPEG comments.
+	FormatMJPEGAVI Format = iota
+
+	// FormatY4M records raw YUV 4:4:4 frames in a YUV4MPEG2 stream. Frame
+	// timestamps are embedded as frame parameters.
+	FormatY4M
+
+	// FormatPNG records each frame as a PNG file inside a directory. Frame
+	// timestamps are embedded as PNG text chunks.
+	FormatPNG
+
+	FormatCount
+)
+
+// String returns the format as a string.
+func (f Format) String() string {
+	switch f {
+	case FormatMJPEGAVI:
+		return "MJPEG-AVI"
+	case FormatY4M:
+		return "Y4M"
+	case FormatPNG:
+		return "PNG"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the format is valid.
+func (f Format) Valid() bool {
+	return f < FormatCount
+}
+
+// extension returns the file name extension associated with the format (empty
+// for formats that are written to a directory).
+func (f Format) extension() string {
+	switch f {
+	case FormatMJPEGAVI:
+		return ".avi"
+	case FormatY4M:
+		return ".y4m"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,106 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/brunoga/robomaster/module/camera"
+)
+
+// pngHeaderSize is the size of the PNG signature plus the IHDR chunk.
+const pngHeaderSize = 8 + 4 + 4 + 13 + 4
+
+// pngWriter writes frames as individual PNG files to a directory.
+type pngWriter struct {
+	dir string
+
+	rgba *image.RGBA
+	buf  bytes.Buffer
+	enc  png.Encoder
+
+	frames int
+	size   int64
+}
+
+func newPNGWriter(dir string, rect image.Rectangle) (*pngWriter, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pngWriter{
+		dir:  dir,
+		rgba: image.NewRGBA(rect),
+		enc:  png.Encoder{CompressionLevel: png.BestSpeed},
+	}, nil
+}
+
+func (w *pngWriter) WriteFrame(frame *camera.RGB,
+	md camera.FrameMetadata) error {
+	frame.CopyToRGBA(w.rgba)
+
+	w.buf.Reset()
+	err := w.enc.Encode(&w.buf, w.rgba)
+	if err != nil {
+		return err
+	}
+
+	data, err := addPNGText(w.buf.Bytes(), "Comment", timestampText(md))
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(w.dir, fmt.Sprintf("frame_%08d.png", w.frames))
+
+	err = os.WriteFile(name, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.frames++
+	w.size += int64(len(data))
+
+	return nil
+}
+
+func (w *pngWriter) Size() int64 {
+	return w.size
+}
+
+func (w *pngWriter) Close() error {
+	return nil
+}
+
+// addPNGText returns the given PNG data with a tEXt chunk with the given
+// keyword and text inserted right after the IHDR chunk.
+func addPNGText(data []byte, keyword, text string) ([]byte, error) {
+	if len(data) < pngHeaderSize ||
+		string(data[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("invalid PNG data")
+	}
+
+	chunkData := make([]byte, 0, len(keyword)+1+len(text))
+	chunkData = append(chunkData, keyword...)
+	chunkData = append(chunkData, 0)
+	chunkData = append(chunkData, text...)
+
+	out := make([]byte, 0, len(data)+12+len(chunkData))
+	out = append(out, data[:pngHeaderSize]...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(chunkData)))
+
+	typeStart := len(out)
+	out = append(out, "tEXt"...)
+	out = append(out, chunkData...)
+	out = binary.BigEndian.AppendUint32(out,
+		crc32.ChecksumIEEE(out[typeStart:]))
+
+	out = append(out, data[pngHeaderSize:]...)
+
+	return out, nil
+}
@@ -0,0 +1,322 @@
+// Package recorder provides client-side recording of the robot camera stream
+// to local files.
+package recorder
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/module/camera"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
+)
+
+// Options are the options for a Recorder.
+type Options struct {
+	// Format is the container format to use.
+	Format Format
+
+	// Path is the base path for the recorded files. Each file (or directory,
+	// for FormatPNG) is named after it with a segment number and the format
+	// extension appended (for example, "video_0001.avi").
+	Path string
+
+	// FrameRate is the nominal frame rate stored in the container headers.
+	// For FormatMJPEGAVI, the actual measured frame rate is stored instead
+	// when the file is closed.
+	FrameRate float64
+
+	// JPEGQuality is the JPEG quality (1 to 100) used for FormatMJPEGAVI.
+	JPEGQuality int
+
+	// MaxFileSize is the size (in bytes) after which a new file is started. 0
+	// means no limit.
+	MaxFileSize int64
+
+	// MaxDuration is the duration after which a new file is started. 0 means
+	// no limit.
+	MaxDuration time.Duration
+
+	// Subscription are the options for the video subscription used to
+	// receive frames. If nil, frames are queued so short encoding hiccups do
+	// not drop frames.
+	Subscription *camera.SubscriptionOptions
+}
+
+// defaultSubscriptionOptions are the subscription options used by default.
+var defaultSubscriptionOptions = camera.SubscriptionOptions{
+	QueueSize:  30,
+	DropPolicy: camera.DropPolicyNewest,
+}
+
+// maxAVIFileSize is the maximum size of AVI files (the classic AVI format can
+// not go over 1GB reliably).
+const maxAVIFileSize = 1 << 30
+
+// frameWriter is the interface implemented by the per-format writers.
+type frameWriter interface {
+	WriteFrame(frame *camera.RGB, md camera.FrameMetadata) error
+	Size() int64
+	Close() error
+}
+
+// Recorder records frames received from a camera module to local files.
+type Recorder struct {
+	cm   *camera.Module
+	l    *logger.Logger
+	opts Options
+
+	m       sync.Mutex
+	t       token.Token
+	started bool
+
+	fw       frameWriter
+	fwRect   image.Rectangle
+	fwStart  time.Time
+	segment  int
+	files    []string
+	frames   uint64
+	firstErr error
+}
+
+// New creates a new Recorder for the given camera module with the given
+// options.
+func New(cm *camera.Module, opts *Options, l *logger.Logger) (*Recorder,
+	error) {
+	if opts == nil {
+		return nil, fmt.Errorf("options must not be nil")
+	}
+
+	if !opts.Format.Valid() {
+		return nil, fmt.Errorf("invalid format: %d", opts.Format)
+	}
+
+	if opts.Path == "" {
+		return nil, fmt.Errorf("path must not be empty")
+	}
+
+	if opts.FrameRate <= 0 {
+		return nil, fmt.Errorf("invalid frame rate: %f", opts.FrameRate)
+	}
+
+	if opts.Format == FormatMJPEGAVI &&
+		(opts.JPEGQuality < 1 || opts.JPEGQuality > 100) {
+		return nil, fmt.Errorf("invalid JPEG quality: %d", opts.JPEGQuality)
+	}
+
+	if opts.MaxFileSize < 0 || opts.MaxDuration < 0 {
+		return nil, fmt.Errorf("invalid rotation limits")
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	o := *opts
+	if o.Format == FormatMJPEGAVI &&
+		(o.MaxFileSize == 0 || o.MaxFileSize > maxAVIFileSize) {
+		o.MaxFileSize = maxAVIFileSize
+	}
+
+	return &Recorder{
+		cm:   cm,
+		l:    l.WithGroup("video_recorder"),
+		opts: o,
+	}, nil
+}
+
+// Start starts recording.
+func (r *Recorder) Start() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.started {
+		return fmt.Errorf("recorder already started")
+	}
+
+	subscriptionOptions := r.opts.Subscription
+	if subscriptionOptions == nil {
+		subscriptionOptions = &defaultSubscriptionOptions
+	}
+
+	t, err := r.cm.AddVideoCallbackWithOptions(r.onFrame,
+		subscriptionOptions)
+	if err != nil {
+		return err
+	}
+
+	r.t = t
+	r.started = true
+	r.firstErr = nil
+
+	return nil
+}
+
+// Stop stops recording and closes the current file. Returns the first error
+// that happened while recording, if any.
+func (r *Recorder) Stop() error {
+	r.m.Lock()
+
+	if !r.started {
+		r.m.Unlock()
+		return fmt.Errorf("recorder not started")
+	}
+
+	r.started = false
+
+	r.m.Unlock()
+
+	// This must not be called with the lock held as it waits for any in
+	// progress onFrame call (which needs the lock) to return.
+	err := r.cm.RemoveVideoCallback(r.t)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	closeErr := r.closeWriterLocked()
+
+	if r.firstErr != nil {
+		return r.firstErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+// Files returns the files (or directories, for FormatPNG) created so far.
+func (r *Recorder) Files() []string {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return append([]string(nil), r.files...)
+}
+
+// Frames returns the number of frames recorded so far.
+func (r *Recorder) Frames() uint64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.frames
+}
+
+func (r *Recorder) onFrame(frame *camera.RGB, md camera.FrameMetadata) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if !r.started {
+		// Late frame delivered while stopping. Writing it would open a new
+		// file that is never closed.
+		return
+	}
+
+	if r.firstErr != nil {
+		// Stop writing after the first error.
+		return
+	}
+
+	err := r.writeFrameLocked(frame, md)
+	if err != nil {
+		r.l.Error("Error recording frame.", "sequence", md.Sequence,
+			"error", err)
+		r.firstErr = err
+	}
+}
+
+func (r *Recorder) writeFrameLocked(frame *camera.RGB,
+	md camera.FrameMetadata) error {
+	if r.fw != nil && r.shouldRotateLocked(frame, md) {
+		err := r.closeWriterLocked()
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.fw == nil {
+		err := r.openWriterLocked(frame.Rect, md.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := r.fw.WriteFrame(frame, md)
+	if err != nil {
+		return err
+	}
+
+	r.frames++
+
+	return nil
+}
+
+func (r *Recorder) shouldRotateLocked(frame *camera.RGB,
+	md camera.FrameMetadata) bool {
+	if frame.Rect != r.fwRect {
+		// Frame geometry changed.
+		return true
+	}
+
+	if r.opts.MaxFileSize > 0 && r.fw.Size() >= r.opts.MaxFileSize {
+		return true
+	}
+
+	return r.opts.MaxDuration > 0 &&
+		md.Time.Sub(r.fwStart) >= r.opts.MaxDuration
+}
+
+func (r *Recorder) openWriterLocked(rect image.Rectangle,
+	start time.Time) error {
+	r.segment++
+
+	path := fmt.Sprintf("%s_%04d%s", r.opts.Path, r.segment,
+		r.opts.Format.extension())
+
+	var fw frameWriter
+	var err error
+
+	switch r.opts.Format {
+	case FormatMJPEGAVI:
+		fw, err = newAVIWriter(path, rect, r.opts.FrameRate,
+			r.opts.JPEGQuality)
+	case FormatY4M:
+		fw, err = newY4MWriter(path, rect, r.opts.FrameRate)
+	case FormatPNG:
+		fw, err = newPNGWriter(path, rect)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.l.Debug("Recording to new file.", "path", path)
+
+	r.fw = fw
+	r.fwRect = rect
+	r.fwStart = start
+	r.files = append(r.files, path)
+
+	return nil
+}
+
+func (r *Recorder) closeWriterLocked() error {
+	if r.fw == nil {
+		return nil
+	}
+
+	err := r.fw.Close()
+	r.fw = nil
+
+	return err
+}
+
+// timestampText returns the text used to embed frame timestamps in recorded
+// files.
+func timestampText(md camera.FrameMetadata) string {
+	return "ts=" + strconv.FormatInt(md.Time.UnixNano(), 10) +
+		";seq=" + strconv.FormatUint(md.Sequence, 10)
+}
@@ -0,0 +1,286 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/camera"
+)
+
+var testRect = image.Rect(0, 0, 32, 24)
+
+func newTestFrame() *camera.RGB {
+	rgb := camera.NewRGB(testRect)
+	for i := range rgb.Pix {
+		rgb.Pix[i] = uint8(i)
+	}
+
+	return rgb
+}
+
+func recordFrames(t *testing.T, opts *Options, count int,
+	interval time.Duration) *Recorder {
+	t.Helper()
+
+	r, err := New(nil, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Frames are only recorded while started. There is no camera module, so
+	// just flag it.
+	r.started = true
+
+	start := time.Unix(1000, 0)
+	for i := 0; i < count; i++ {
+		r.onFrame(newTestFrame(), camera.FrameMetadata{
+			Sequence: uint64(i + 1),
+			Time:     start.Add(time.Duration(i) * interval),
+			Format:   camera.VideoFormat720p_30,
+		})
+	}
+
+	if r.firstErr != nil {
+		t.Fatalf("error recording: %v", r.firstErr)
+	}
+
+	if err := r.closeWriterLocked(); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestRecordAVI(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "video")
+
+	r := recordFrames(t, &Options{
+		Format:      FormatMJPEGAVI,
+		Path:        base,
+		FrameRate:   30,
+		JPEGQuality: 80,
+	}, 10, 100*time.Millisecond)
+
+	files := r.Files()
+	if len(files) != 1 || files[0] != base+"_0001.avi" {
+		t.Fatalf("unexpected files: %v", files)
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "AVI " {
+		t.Fatalf("invalid RIFF header")
+	}
+
+	if riffSize := binary.LittleEndian.Uint32(data[4:]); int(riffSize) !=
+		len(data)-8 {
+		t.Errorf("unexpected RIFF size %d for file size %d", riffSize,
+			len(data))
+	}
+
+	if string(data[aviHeaderSize-4:aviHeaderSize]) != "movi" {
+		t.Fatalf("movi list not found at expected offset")
+	}
+
+	// Total frames in avih and measured frame rate (10 fps).
+	if frames := binary.LittleEndian.Uint32(data[48:]); frames != 10 {
+		t.Errorf("unexpected frame count: %d", frames)
+	}
+
+	if usPerFrame := binary.LittleEndian.Uint32(data[32:]); usPerFrame !=
+		100000 {
+		t.Errorf("unexpected microseconds per frame: %d", usPerFrame)
+	}
+
+	// First frame must be a valid JPEG with the embedded timestamp.
+	if string(data[aviHeaderSize:aviHeaderSize+4]) != "00dc" {
+		t.Fatalf("first frame chunk not found")
+	}
+
+	size := binary.LittleEndian.Uint32(data[aviHeaderSize+4:])
+	frame := data[aviHeaderSize+8 : aviHeaderSize+8+int(size)]
+
+	if !bytes.Contains(frame, []byte("ts=1000000000000;seq=1")) {
+		t.Errorf("timestamp comment not found in first frame")
+	}
+
+	if _, err := jpeg.Decode(bytes.NewReader(frame)); err != nil {
+		t.Errorf("error decoding first frame: %v", err)
+	}
+}
+
+func TestRecordY4M(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "video")
+
+	r := recordFrames(t, &Options{
+		Format:    FormatY4M,
+		Path:      base,
+		FrameRate: 29.97,
+	}, 3, 33*time.Millisecond)
+
+	data, err := os.ReadFile(r.Files()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := "YUV4MPEG2 W32 H24 F29970:1000 Ip A1:1 C444\n"
+	if !strings.HasPrefix(string(data), header) {
+		t.Fatalf("unexpected header: %q", data[:len(header)])
+	}
+
+	frameHeader := "FRAME Xts=1000000000000;seq=1\n"
+	if !strings.HasPrefix(string(data[len(header):]), frameHeader) {
+		t.Errorf("unexpected frame header")
+	}
+
+	planeSize := 3 * testRect.Dx() * testRect.Dy()
+	want := len(header) + 3*planeSize + len(frameHeader) +
+		len("FRAME Xts=1000033000000;seq=2\n") +
+		len("FRAME Xts=1000066000000;seq=3\n")
+	if len(data) != want {
+		t.Errorf("unexpected file size: got %d, want %d", len(data), want)
+	}
+}
+
+func TestRecordPNG(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "video")
+
+	r := recordFrames(t, &Options{
+		Format:    FormatPNG,
+		Path:      base,
+		FrameRate: 30,
+	}, 2, 33*time.Millisecond)
+
+	data, err := os.ReadFile(filepath.Join(r.Files()[0], "frame_00000001.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(data, []byte("tEXtComment\x00ts=1000033000000;seq=2")) {
+		t.Errorf("timestamp text chunk not found")
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("error decoding PNG: %v", err)
+	}
+
+	if img.Bounds() != testRect {
+		t.Errorf("unexpected bounds: %v", img.Bounds())
+	}
+}
+
+func TestRotation(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "video")
+
+	r := recordFrames(t, &Options{
+		Format:      FormatY4M,
+		Path:        base,
+		FrameRate:   10,
+		MaxDuration: 500 * time.Millisecond,
+	}, 12, 100*time.Millisecond)
+
+	files := r.Files()
+	if len(files) != 3 {
+		t.Fatalf("unexpected files: %v", files)
+	}
+
+	if r.Frames() != 12 {
+		t.Errorf("unexpected frame count: %d", r.Frames())
+	}
+
+	r = recordFrames(t, &Options{
+		Format:      FormatPNG,
+		Path:        base + "_png",
+		FrameRate:   10,
+		MaxFileSize: 1,
+	}, 3, 100*time.Millisecond)
+
+	if files := r.Files(); len(files) != 3 {
+		t.Errorf("unexpected files: %v", files)
+	}
+}
+
+func TestLateFrameAfterStop(t *testing.T) {
+	r := recordFrames(t, &Options{
+		Format:    FormatY4M,
+		Path:      filepath.Join(t.TempDir(), "video"),
+		FrameRate: 10,
+	}, 2, 100*time.Millisecond)
+
+	r.started = false
+
+	r.onFrame(newTestFrame(), camera.FrameMetadata{
+		Sequence: 3,
+		Time:     time.Unix(1001, 0),
+		Format:   camera.VideoFormat720p_30,
+	})
+
+	if r.fw != nil {
+		t.Error("late frame opened a new file")
+	}
+
+	if files := r.Files(); len(files) != 1 || r.Frames() != 2 {
+		t.Errorf("unexpected files %v (%d frames)", files, r.Frames())
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	invalid := []*Options{
+		nil,
+		{Format: FormatCount, Path: "x", FrameRate: 30},
+		{Format: FormatY4M, FrameRate: 30},
+		{Format: FormatY4M, Path: "x"},
+		{Format: FormatMJPEGAVI, Path: "x", FrameRate: 30},
+		{Format: FormatY4M, Path: "x", FrameRate: 30, MaxFileSize: -1},
+	}
+
+	for i, opts := range invalid {
+		if _, err := New(nil, opts, nil); err == nil {
+			t.Errorf("expected error for options %d", i)
+		}
+	}
+}
+
+func TestAVIWriterSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "video.avi")
+
+	w, err := newAVIWriter(path, testRect, 30, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		err = w.WriteFrame(newTestFrame(), camera.FrameMetadata{
+			Sequence: uint64(i + 1), Time: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size := w.Size()
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Size() != size {
+		t.Errorf("unexpected size: got %d, file has %d", size, fi.Size())
+	}
+}
@@ -0,0 +1,103 @@
+package recorder
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+
+	"github.com/brunoga/robomaster/module/camera"
+)
+
+// y4mWriter writes raw YUV 4:4:4 frames to a YUV4MPEG2 file.
+type y4mWriter struct {
+	f  *os.File
+	bw *bufio.Writer
+
+	rect  image.Rectangle
+	plane []byte
+
+	size int64
+}
+
+func newY4MWriter(path string, rect image.Rectangle,
+	frameRate float64) (*y4mWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &y4mWriter{
+		f:     f,
+		bw:    bufio.NewWriterSize(f, 3*rect.Dx()*rect.Dy()),
+		rect:  rect,
+		plane: make([]byte, 3*rect.Dx()*rect.Dy()),
+	}
+
+	num, den := frameRateFraction(frameRate)
+
+	n, err := fmt.Fprintf(w.bw, "YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C444\n",
+		rect.Dx(), rect.Dy(), num, den)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	w.size = int64(n)
+
+	return w, nil
+}
+
+func (w *y4mWriter) WriteFrame(frame *camera.RGB,
+	md camera.FrameMetadata) error {
+	n, err := fmt.Fprintf(w.bw, "FRAME X%s\n", timestampText(md))
+	if err != nil {
+		return err
+	}
+
+	w.size += int64(n)
+
+	// Planar Y, Cb and Cr.
+	width, height := w.rect.Dx(), w.rect.Dy()
+	planeSize := width * height
+	for y := 0; y < height; y++ {
+		row := frame.Pix[y*frame.Stride : y*frame.Stride+3*width]
+		for x := 0; x < width; x++ {
+			yy, cb, cr := color.RGBToYCbCr(row[3*x], row[3*x+1], row[3*x+2])
+			i := y*width + x
+			w.plane[i] = yy
+			w.plane[planeSize+i] = cb
+			w.plane[2*planeSize+i] = cr
+		}
+	}
+
+	n, err = w.bw.Write(w.plane)
+	w.size += int64(n)
+
+	return err
+}
+
+func (w *y4mWriter) Size() int64 {
+	return w.size
+}
+
+func (w *y4mWriter) Close() error {
+	err := w.bw.Flush()
+	if err != nil {
+		w.f.Close()
+		return err
+	}
+
+	return w.f.Close()
+}
+
+// frameRateFraction returns the given frame rate as a fraction.
+func frameRateFraction(frameRate float64) (int, int) {
+	if frameRate == math.Trunc(frameRate) {
+		return int(frameRate), 1
+	}
+
+	return int(math.Round(frameRate * 1000)), 1000
+}
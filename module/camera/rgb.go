@@ -1,6 +1,7 @@
 package camera
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 )
@@ -17,11 +18,14 @@ func NewRGB(r image.Rectangle) *RGB {
 	return &RGB{Pix: buf, Stride: 3 * w, Rect: r}
 }
 
-func NewRGBFromBytes(data []byte, r image.Rectangle) *RGB {
+// NewRGBFromBytes returns a new RGB image backed by the given data. Returns an
+// error if the data size does not match the given rectangle.
+func NewRGBFromBytes(data []byte, r image.Rectangle) (*RGB, error) {
 	if len(data) != 3*r.Dx()*r.Dy() {
-		panic("unexpected image data")
+		return nil, fmt.Errorf("unexpected image data size for %dx%d "+
+			"image: %d", r.Dx(), r.Dy(), len(data))
 	}
-	return &RGB{Pix: data, Stride: 3 * r.Dx(), Rect: r}
+	return &RGB{Pix: data, Stride: 3 * r.Dx(), Rect: r}, nil
 }
 
 func (im *RGB) ColorModel() color.Model {
@@ -54,3 +58,20 @@ func (im *RGB) Set(x, y int, c color.Color) {
 func (im *RGB) PixOffset(x, y int) int {
 	return (y-im.Rect.Min.Y)*im.Stride + (x-im.Rect.Min.X)*3
 }
+
+// CopyToRGBA copies the image pixels to the given RGBA image, which must have
+// the same bounds. This is mostly useful for encoders that have fast paths for
+// RGBA images.
+func (im *RGB) CopyToRGBA(dst *image.RGBA) {
+	w, h := im.Rect.Dx(), im.Rect.Dy()
+	for y := 0; y < h; y++ {
+		srcRow := im.Pix[y*im.Stride : y*im.Stride+3*w]
+		dstRow := dst.Pix[y*dst.Stride : y*dst.Stride+4*w]
+		for x := 0; x < w; x++ {
+			dstRow[4*x+0] = srcRow[3*x+0]
+			dstRow[4*x+1] = srcRow[3*x+1]
+			dstRow[4*x+2] = srcRow[3*x+2]
+			dstRow[4*x+3] = 255
+		}
+	}
+}
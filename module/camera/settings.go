@@ -0,0 +1,193 @@
+package camera
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+// streamStallThreshold is the time without frames after which we consider the
+// video stream stalled and request a new I-frame when frames resume.
+const streamStallThreshold = 1 * time.Second
+
+// AntiFlicker returns the current anti-flicker setting.
+func (m *Module) AntiFlicker() (AntiFlicker, error) {
+	r, err := m.UB().GetKeyValueSync(key.KeyCameraAntiFlicker, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return AntiFlicker(r.Value().(*value.Uint64).Value), nil
+}
+
+// SetAntiFlicker sets the anti-flicker setting. It should match the frequency
+// of the mains power where the robot is being used.
+func (m *Module) SetAntiFlicker(af AntiFlicker) error {
+	if !af.Valid() {
+		return fmt.Errorf("invalid anti-flicker setting: %d", af)
+	}
+
+	return m.UB().SetKeyValueSync(key.KeyCameraAntiFlicker,
+		&value.Uint64{Value: uint64(af)})
+}
+
+// IsTimeSynced returns whether the camera clock was synced.
+func (m *Module) IsTimeSynced() (bool, error) {
+	r, err := m.UB().GetKeyValueSync(key.KeyCameraIsTimeSynced, false)
+	if err != nil {
+		return false, err
+	}
+
+	return r.Value().(*value.Bool).Value, nil
+}
+
+// Date returns the current camera date and time.
+func (m *Module) Date() (time.Time, error) {
+	r, err := m.UB().GetKeyValueSync(key.KeyCameraDate, false)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	d := r.Value().(*value.CameraDate)
+
+	return time.Date(d.Year, time.Month(d.Month), d.Day, d.Hour, d.Minute,
+		d.Second, 0, time.Local), nil
+}
+
+// SetDate sets the camera date and time (in the local time zone).
+func (m *Module) SetDate(t time.Time) error {
+	t = t.Local()
+
+	return m.UB().SetKeyValueSync(key.KeyCameraDate, &value.CameraDate{
+		Year:   t.Year(),
+		Month:  int(t.Month()),
+		Day:    t.Day(),
+		Hour:   t.Hour(),
+		Minute: t.Minute(),
+		Second: t.Second(),
+	})
+}
+
+// SyncDate sets the camera date and time to the host date and time.
+func (m *Module) SyncDate() error {
+	return m.SetDate(time.Now())
+}
+
+// RequestIFrame requests the robot to send an I-frame (key frame) as soon as
+// possible. This is useful to recover the video stream after a stall. It is
+// also done automatically when frames resume after a stall.
+func (m *Module) RequestIFrame() error {
+	return m.UB().PerformActionForKeySync(key.KeyCameraRequestIFrame, nil)
+}
+
+// Cameras returns the cameras available in the robot.
+func (m *Module) Cameras() ([]Index, error) {
+	var cameras []Index
+
+	for _, c := range []struct {
+		i Index
+		k *key.Key
+	}{
+		{IndexMain, key.KeyCameraHasMainCamera},
+		{IndexSecondary, key.KeyCameraHasSecondaryCamera},
+	} {
+		r, err := m.UB().GetKeyValueSync(c.k, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Value().(*value.Bool).Value {
+			cameras = append(cameras, c.i)
+		}
+	}
+
+	return cameras, nil
+}
+
+// CurrentCamera returns the camera currently in use.
+func (m *Module) CurrentCamera() (Index, error) {
+	r, err := m.UB().GetKeyValueSync(key.KeyCameraCurrentCameraIndex, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return Index(r.Value().(*value.Uint64).Value), nil
+}
+
+// SwitchCamera switches to the camera with the given index. It is a no-op if
+// the camera is already in use.
+func (m *Module) SwitchCamera(i Index) error {
+	if !i.Valid() {
+		return fmt.Errorf("invalid camera index: %d", i)
+	}
+
+	current, err := m.CurrentCamera()
+	if err != nil {
+		return err
+	}
+
+	if current == i {
+		return nil
+	}
+
+	cameras, err := m.Cameras()
+	if err != nil {
+		return err
+	}
+
+	available := false
+	for _, c := range cameras {
+		if c == i {
+			available = true
+			break
+		}
+	}
+
+	if !available {
+		return fmt.Errorf("camera %s not available", i)
+	}
+
+	return m.UB().PerformActionForKeySync(key.KeyCameraSwitch, nil)
+}
+
+// syncDateIfNeeded syncs the camera date and time to the host if it was not
+// synced yet.
+func (m *Module) syncDateIfNeeded() {
+	synced, err := m.IsTimeSynced()
+	if err != nil {
+		m.Logger().Error("Error checking camera time sync.", "error", err)
+		return
+	}
+
+	if synced {
+		return
+	}
+
+	err = m.SyncDate()
+	if err != nil {
+		m.Logger().Error("Error syncing camera date.", "error", err)
+		return
+	}
+
+	m.Logger().Debug("Camera date synced.")
+}
+
+// checkStreamStall requests an I-frame if frames resumed after a stall.
+func (m *Module) checkStreamStall(t time.Time) {
+	last := m.lastFrameTime.Swap(&t)
+	if last == nil || t.Sub(*last) < streamStallThreshold {
+		return
+	}
+
+	m.Logger().Debug("Video stream stall detected. Requesting I-frame.",
+		"stall", t.Sub(*last))
+
+	go func() {
+		err := m.RequestIFrame()
+		if err != nil {
+			m.Logger().Error("Error requesting I-frame.", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package camera
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/mock"
+)
+
+func TestCheckStreamStall(t *testing.T) {
+	uw, ub := mock.NewStartedUnityBridge(t)
+
+	m, err := New(ub, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := func() int {
+		code := event.NewFromTypeAndSubType(event.TypePerformAction,
+			key.KeyCameraRequestIFrame.SubType()).Code()
+
+		n := 0
+		for _, e := range uw.Sent() {
+			if e.Code == code {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	now := time.Now()
+
+	// Stopping the stream is not a stall.
+	m.checkStreamStall(now)
+
+	err = m.stopStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.checkStreamStall(now.Add(2 * streamStallThreshold))
+
+	time.Sleep(50 * time.Millisecond)
+
+	if n := requests(); n != 0 {
+		t.Fatalf("got %d I-frame requests after restarting the stream, "+
+			"want 0", n)
+	}
+
+	m.checkStreamStall(now.Add(4 * streamStallThreshold))
+
+	time.Sleep(50 * time.Millisecond)
+
+	if n := requests(); n != 1 {
+		t.Errorf("got %d I-frame requests after a stall, want 1", n)
+	}
+}
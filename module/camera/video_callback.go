@@ -1,5 +1,22 @@
 package camera
 
+import "time"
+
+// FrameMetadata contains information about a received video frame.
+type FrameMetadata struct {
+	// Sequence is the sequence number of the frame. It is incremented for
+	// every frame received from the robot (including malformed ones), so gaps
+	// indicate frames that were not delivered.
+	Sequence uint64
+
+	// Time is the time the frame was received.
+	Time time.Time
+
+	// Format is the video format of the frame.
+	Format VideoFormat
+}
+
 // VideoCallback is the type of the callback function used to receive video
-// frames.
-type VideoCallback func(frame *RGB)
+// frames. The frame is only valid until the callback returns (its buffer is
+// reused afterwards) so callbacks must copy any data they want to keep.
+type VideoCallback func(frame *RGB, metadata FrameMetadata)
@@ -1,5 +1,7 @@
 package camera
 
+import "image"
+
 type VideoFormat uint8
 
 const (
@@ -30,3 +32,59 @@ func (vf VideoFormat) String() string {
 func (vf VideoFormat) Valid() bool {
 	return vf < VideoFormatCount
 }
+
+// Size returns the frame width and height for the video format. Returns 0, 0
+// for invalid formats.
+func (vf VideoFormat) Size() (int, int) {
+	switch vf {
+	case VideoFormat720p_30, VideoFormat720p_60:
+		return 1280, 720
+	case VideoFormat1080p_30, VideoFormat1080p_60:
+		return 1920, 1080
+	default:
+		return 0, 0
+	}
+}
+
+// FrameRate returns the nominal frame rate (in frames per second) for the
+// video format. Returns 0 for invalid formats.
+func (vf VideoFormat) FrameRate() float64 {
+	switch vf {
+	case VideoFormat720p_30, VideoFormat1080p_30:
+		return 30
+	case VideoFormat720p_60, VideoFormat1080p_60:
+		return 60
+	default:
+		return 0
+	}
+}
+
+// Rect returns the frame bounds for the video format.
+func (vf VideoFormat) Rect() image.Rectangle {
+	w, h := vf.Size()
+	return image.Rect(0, 0, w, h)
+}
+
+// FrameSize returns the size in bytes of a RGB frame for the video format.
+func (vf VideoFormat) FrameSize() int {
+	w, h := vf.Size()
+	return 3 * w * h
+}
+
+// videoFormatForFrameSize returns the video format to use for a frame with the
+// given size in bytes. The preferred format is returned if it matches,
+// otherwise the first matching valid format is. Returns false if no format
+// matches.
+func videoFormatForFrameSize(size int, preferred VideoFormat) (VideoFormat, bool) {
+	if preferred.Valid() && preferred.FrameSize() == size {
+		return preferred, true
+	}
+
+	for vf := VideoFormat(0); vf.Valid(); vf++ {
+		if vf.FrameSize() == size {
+			return vf, true
+		}
+	}
+
+	return VideoFormatCount, false
+}
@@ -0,0 +1,45 @@
+package camera
+
+import "testing"
+
+func TestVideoFormatForFrameSize(t *testing.T) {
+	tests := []struct {
+		size      int
+		preferred VideoFormat
+		want      VideoFormat
+		wantOk    bool
+	}{
+		{3 * 1280 * 720, VideoFormat720p_30, VideoFormat720p_30, true},
+		{3 * 1280 * 720, VideoFormat720p_60, VideoFormat720p_60, true},
+		{3 * 1280 * 720, VideoFormat1080p_30, VideoFormat720p_30, true},
+		{3 * 1920 * 1080, VideoFormat720p_30, VideoFormat1080p_30, true},
+		{3 * 1920 * 1080, VideoFormatCount, VideoFormat1080p_30, true},
+		{0, VideoFormat720p_30, VideoFormatCount, false},
+		{3*1280*720 - 1, VideoFormat720p_30, VideoFormatCount, false},
+	}
+
+	for _, test := range tests {
+		got, ok := videoFormatForFrameSize(test.size, test.preferred)
+		if got != test.want || ok != test.wantOk {
+			t.Errorf("videoFormatForFrameSize(%d, %s) = %s, %v; want %s, %v",
+				test.size, test.preferred, got, ok, test.want, test.wantOk)
+		}
+	}
+}
+
+func TestNewRGBFromBytesInvalidSize(t *testing.T) {
+	_, err := NewRGBFromBytes(make([]byte, 10), VideoFormat720p_30.Rect())
+	if err == nil {
+		t.Errorf("expected error for invalid data size")
+	}
+
+	rgb, err := NewRGBFromBytes(make([]byte, VideoFormat720p_30.FrameSize()),
+		VideoFormat720p_30.Rect())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rgb.Bounds() != VideoFormat720p_30.Rect() {
+		t.Errorf("unexpected bounds: %v", rgb.Bounds())
+	}
+}
@@ -0,0 +1,134 @@
+package camera
+
+import (
+	"sync"
+	"time"
+)
+
+// VideoStats are live statistics about the video stream received from the
+// robot.
+type VideoStats struct {
+	// TransferSpeed is the last video transfer speed reported by the robot.
+	// It is assumed to be in bytes per second, but the unit was not verified.
+	TransferSpeed uint64
+
+	// Bitrate is the video bitrate (in bits per second) derived from
+	// TransferSpeed (so it is only correct if TransferSpeed is in bytes per
+	// second).
+	Bitrate uint64
+
+	// FrameRate is the measured frame rate (in frames per second).
+	FrameRate float64
+
+	// Jitter is the smoothed inter-frame arrival jitter (the mean deviation of
+	// frame intervals from the average frame interval).
+	Jitter time.Duration
+
+	// Frames is the number of frames received.
+	Frames uint64
+
+	// DroppedFrames is the estimated number of frames that were never
+	// received (based on gaps in frame arrival relative to the nominal frame
+	// rate of the current video format).
+	DroppedFrames uint64
+
+	// MalformedFrames is the number of frames received that could not be
+	// decoded.
+	MalformedFrames uint64
+
+	// LastFrameTime is the time the last frame was received.
+	LastFrameTime time.Time
+
+	// Time is the time these stats were last updated (either because a frame
+	// was received or because the robot reported its video transfer speed).
+	Time time.Time
+}
+
+// VideoStatsCallback is the type of the callback function used to receive
+// video stats updates.
+type VideoStatsCallback func(stats VideoStats)
+
+const (
+	// videoStatsGain is the gain used for smoothing frame interval and
+	// jitter estimates (same as RFC 3550 uses for jitter).
+	videoStatsGain = 1.0 / 16.0
+
+	// droppedFrameThreshold is the ratio between a frame interval and the
+	// nominal interval above which we assume frames were dropped.
+	droppedFrameThreshold = 1.5
+)
+
+// videoStatsTracker keeps track of video stream statistics.
+type videoStatsTracker struct {
+	m sync.Mutex
+
+	stats VideoStats
+
+	lastFrame   time.Time
+	avgInterval float64 // Seconds.
+	jitter      float64 // Seconds.
+}
+
+func (vst *videoStatsTracker) onFrame(t time.Time, nominalFrameRate float64) {
+	vst.m.Lock()
+	defer vst.m.Unlock()
+
+	vst.stats.Frames++
+	vst.stats.LastFrameTime = t
+	vst.stats.Time = t
+
+	if vst.lastFrame.IsZero() {
+		vst.lastFrame = t
+		return
+	}
+
+	interval := t.Sub(vst.lastFrame).Seconds()
+	if interval <= 0 {
+		// Frames might be delivered slightly out of order.
+		return
+	}
+
+	vst.lastFrame = t
+
+	if nominalFrameRate > 0 {
+		nominalInterval := 1.0 / nominalFrameRate
+		if interval > droppedFrameThreshold*nominalInterval {
+			missing := uint64(interval/nominalInterval+0.5) - 1
+			vst.stats.DroppedFrames += missing
+		}
+	}
+
+	if vst.avgInterval == 0 {
+		vst.avgInterval = interval
+	} else {
+		deviation := interval - vst.avgInterval
+		if deviation < 0 {
+			deviation = -deviation
+		}
+
+		vst.jitter += (deviation - vst.jitter) * videoStatsGain
+		vst.avgInterval += (interval - vst.avgInterval) * videoStatsGain
+	}
+
+	vst.stats.FrameRate = 1.0 / vst.avgInterval
+	vst.stats.Jitter = time.Duration(vst.jitter * float64(time.Second))
+}
+
+func (vst *videoStatsTracker) onTransferSpeed(t time.Time, speed uint64) {
+	vst.m.Lock()
+	defer vst.m.Unlock()
+
+	vst.stats.TransferSpeed = speed
+	vst.stats.Bitrate = speed * 8
+	vst.stats.Time = t
+}
+
+func (vst *videoStatsTracker) snapshot(malformedFrames uint64) VideoStats {
+	vst.m.Lock()
+	defer vst.m.Unlock()
+
+	stats := vst.stats
+	stats.MalformedFrames = malformedFrames
+
+	return stats
+}
@@ -0,0 +1,82 @@
+package camera
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVideoStatsSteadyStream(t *testing.T) {
+	var vst videoStatsTracker
+
+	start := time.Unix(1000, 0)
+	interval := time.Second / 30
+	for i := 0; i < 100; i++ {
+		vst.onFrame(start.Add(time.Duration(i)*interval), 30)
+	}
+
+	stats := vst.snapshot(2)
+
+	if stats.Frames != 100 {
+		t.Errorf("unexpected frame count: %d", stats.Frames)
+	}
+
+	if stats.FrameRate < 29.9 || stats.FrameRate > 30.1 {
+		t.Errorf("unexpected frame rate: %f", stats.FrameRate)
+	}
+
+	if stats.Jitter > time.Microsecond {
+		t.Errorf("unexpected jitter: %s", stats.Jitter)
+	}
+
+	if stats.DroppedFrames != 0 {
+		t.Errorf("unexpected dropped frames: %d", stats.DroppedFrames)
+	}
+
+	if stats.MalformedFrames != 2 {
+		t.Errorf("unexpected malformed frames: %d", stats.MalformedFrames)
+	}
+}
+
+func TestVideoStatsDroppedFramesAndJitter(t *testing.T) {
+	var vst videoStatsTracker
+
+	interval := time.Second / 30
+	now := time.Unix(1000, 0)
+	for i := 0; i < 50; i++ {
+		// Alternate early and late frames.
+		d := interval
+		if i%2 == 0 {
+			d += 5 * time.Millisecond
+		} else {
+			d -= 5 * time.Millisecond
+		}
+
+		now = now.Add(d)
+		vst.onFrame(now, 30)
+	}
+
+	// A gap of 4 intervals means 3 frames are missing.
+	now = now.Add(4 * interval)
+	vst.onFrame(now, 30)
+
+	stats := vst.snapshot(0)
+
+	if stats.DroppedFrames != 3 {
+		t.Errorf("unexpected dropped frames: %d", stats.DroppedFrames)
+	}
+
+	if stats.Jitter < time.Millisecond {
+		t.Errorf("expected jitter, got %s", stats.Jitter)
+	}
+}
+
+func TestVideoStatsTransferSpeed(t *testing.T) {
+	var vst videoStatsTracker
+
+	vst.onTransferSpeed(time.Now(), 1000)
+
+	stats := vst.snapshot(0)
+	if stats.TransferSpeed != 1000 || stats.Bitrate != 8000 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
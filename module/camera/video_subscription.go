@@ -0,0 +1,194 @@
+package camera
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/brunoga/robomaster/support/token"
+)
+
+// DropPolicy controls what happens when a new frame arrives and a video
+// subscription queue is full.
+type DropPolicy uint8
+
+const (
+	// DropPolicyOldest drops the oldest queued frame to make room for the
+	// new one.
+	DropPolicyOldest DropPolicy = iota
+
+	// DropPolicyNewest drops the new frame.
+	DropPolicyNewest
+
+	// DropPolicyBlock blocks frame delivery until there is room in the queue.
+	// Note this will stall delivery to all other subscribers too.
+	DropPolicyBlock
+
+	DropPolicyCount
+)
+
+// String returns the drop policy as a string.
+func (dp DropPolicy) String() string {
+	switch dp {
+	case DropPolicyOldest:
+		return "DropOldest"
+	case DropPolicyNewest:
+		return "DropNewest"
+	case DropPolicyBlock:
+		return "Block"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the drop policy is valid.
+func (dp DropPolicy) Valid() bool {
+	return dp < DropPolicyCount
+}
+
+// SubscriptionOptions are the options for a video subscription.
+type SubscriptionOptions struct {
+	// QueueSize is the maximum number of frames queued for the subscriber.
+	QueueSize int
+
+	// DropPolicy is the policy used when the queue is full.
+	DropPolicy DropPolicy
+}
+
+// DefaultSubscriptionOptions are the options used when none are given. They
+// favor latency over completeness.
+var DefaultSubscriptionOptions = SubscriptionOptions{
+	QueueSize:  2,
+	DropPolicy: DropPolicyOldest,
+}
+
+// SubscriptionStats are statistics about frames delivered to a video
+// subscription.
+type SubscriptionStats struct {
+	// Delivered is the number of frames queued for the subscriber.
+	Delivered uint64
+
+	// Dropped is the number of frames dropped due to the queue being full.
+	Dropped uint64
+}
+
+// VideoSubscription is a bounded queue of video frames. Received frames must
+// be released by the subscriber.
+type VideoSubscription struct {
+	m *Module
+	t token.Token
+
+	dropPolicy DropPolicy
+
+	frames chan *Frame
+
+	quit      chan struct{}
+	closeOnce sync.Once
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+func newVideoSubscription(m *Module, t token.Token,
+	opts *SubscriptionOptions) (*VideoSubscription, error) {
+	if opts == nil {
+		opts = &DefaultSubscriptionOptions
+	}
+
+	if opts.QueueSize <= 0 {
+		return nil, fmt.Errorf("invalid queue size: %d", opts.QueueSize)
+	}
+
+	if !opts.DropPolicy.Valid() {
+		return nil, fmt.Errorf("invalid drop policy: %d", opts.DropPolicy)
+	}
+
+	return &VideoSubscription{
+		m:          m,
+		t:          t,
+		dropPolicy: opts.DropPolicy,
+		frames:     make(chan *Frame, opts.QueueSize),
+		quit:       make(chan struct{}),
+	}, nil
+}
+
+// Token returns the token associated with this subscription.
+func (vs *VideoSubscription) Token() token.Token {
+	return vs.t
+}
+
+// Frames returns the channel where frames are delivered. The channel is closed
+// when the subscription is closed. Each received frame must be released.
+func (vs *VideoSubscription) Frames() <-chan *Frame {
+	return vs.frames
+}
+
+// Stats returns the current subscription statistics.
+func (vs *VideoSubscription) Stats() SubscriptionStats {
+	return SubscriptionStats{
+		Delivered: vs.delivered.Load(),
+		Dropped:   vs.dropped.Load(),
+	}
+}
+
+// Close closes the subscription. Frames still queued are released.
+func (vs *VideoSubscription) Close() error {
+	return vs.m.removeSubscription(vs.t)
+}
+
+// deliver queues the given frame according to the drop policy. The frame is
+// released if it could not be queued.
+func (vs *VideoSubscription) deliver(f *Frame) {
+	select {
+	case vs.frames <- f:
+		vs.delivered.Add(1)
+		return
+	default:
+	}
+
+	switch vs.dropPolicy {
+	case DropPolicyOldest:
+		for {
+			select {
+			case old := <-vs.frames:
+				old.Release()
+				vs.dropped.Add(1)
+			default:
+			}
+
+			select {
+			case vs.frames <- f:
+				vs.delivered.Add(1)
+				return
+			default:
+			}
+		}
+	case DropPolicyNewest:
+		f.Release()
+		vs.dropped.Add(1)
+	case DropPolicyBlock:
+		select {
+		case vs.frames <- f:
+			vs.delivered.Add(1)
+		case <-vs.quit:
+			f.Release()
+		}
+	}
+}
+
+// shutdown signals any blocked deliveries to give up.
+func (vs *VideoSubscription) shutdown() {
+	vs.closeOnce.Do(func() {
+		close(vs.quit)
+	})
+}
+
+// drain closes the frames channel and releases any queued frames. Must only be
+// called when no deliveries can happen anymore.
+func (vs *VideoSubscription) drain() {
+	close(vs.frames)
+
+	for f := range vs.frames {
+		f.Release()
+	}
+}
@@ -0,0 +1,151 @@
+package camera
+
+import (
+	"testing"
+)
+
+func newTestFrame(fp *framePool, sequence uint64) *Frame {
+	return fp.get(make([]byte, VideoFormat720p_30.FrameSize()), FrameMetadata{
+		Sequence: sequence,
+		Format:   VideoFormat720p_30,
+	}, 1).frame()
+}
+
+func TestVideoSubscriptionDropOldest(t *testing.T) {
+	vs, err := newVideoSubscription(nil, 1, &SubscriptionOptions{
+		QueueSize:  2,
+		DropPolicy: DropPolicyOldest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := newFramePool()
+	for i := uint64(1); i <= 5; i++ {
+		vs.deliver(newTestFrame(fp, i))
+	}
+
+	stats := vs.Stats()
+	if stats.Delivered != 5 || stats.Dropped != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	for _, want := range []uint64{4, 5} {
+		f := <-vs.Frames()
+		if f.Sequence != want {
+			t.Errorf("got frame %d, want %d", f.Sequence, want)
+		}
+		f.Release()
+	}
+}
+
+func TestVideoSubscriptionDropNewest(t *testing.T) {
+	vs, err := newVideoSubscription(nil, 1, &SubscriptionOptions{
+		QueueSize:  2,
+		DropPolicy: DropPolicyNewest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := newFramePool()
+	for i := uint64(1); i <= 5; i++ {
+		vs.deliver(newTestFrame(fp, i))
+	}
+
+	stats := vs.Stats()
+	if stats.Delivered != 2 || stats.Dropped != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	for _, want := range []uint64{1, 2} {
+		f := <-vs.Frames()
+		if f.Sequence != want {
+			t.Errorf("got frame %d, want %d", f.Sequence, want)
+		}
+		f.Release()
+	}
+}
+
+func TestVideoSubscriptionBlockShutdown(t *testing.T) {
+	vs, err := newVideoSubscription(nil, 1, &SubscriptionOptions{
+		QueueSize:  1,
+		DropPolicy: DropPolicyBlock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := newFramePool()
+	vs.deliver(newTestFrame(fp, 1))
+
+	done := make(chan struct{})
+	go func() {
+		vs.deliver(newTestFrame(fp, 2))
+		close(done)
+	}()
+
+	vs.shutdown()
+	<-done
+
+	vs.drain()
+
+	if stats := vs.Stats(); stats.Delivered != 1 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestVideoSubscriptionInvalidOptions(t *testing.T) {
+	_, err := newVideoSubscription(nil, 1, &SubscriptionOptions{})
+	if err == nil {
+		t.Errorf("expected error for zero queue size")
+	}
+
+	_, err = newVideoSubscription(nil, 1, &SubscriptionOptions{
+		QueueSize:  1,
+		DropPolicy: DropPolicyCount,
+	})
+	if err == nil {
+		t.Errorf("expected error for invalid drop policy")
+	}
+}
+
+func TestFrameRelease(t *testing.T) {
+	fp := newFramePool()
+
+	b := fp.get(make([]byte, VideoFormat720p_30.FrameSize()), FrameMetadata{
+		Format: VideoFormat720p_30,
+	}, 2)
+
+	f1, f2 := b.frame(), b.frame()
+
+	if f1.Bounds() != VideoFormat720p_30.Rect() {
+		t.Errorf("unexpected bounds: %v", f1.Bounds())
+	}
+
+	f1.Release()
+	if refs := b.refs.Load(); refs != 1 {
+		t.Errorf("unexpected refs after first release: %d", refs)
+	}
+
+	// Extra releases must not release the other subscriber reference.
+	f1.Release()
+	if refs := b.refs.Load(); refs != 1 {
+		t.Errorf("unexpected refs after extra release: %d", refs)
+	}
+
+	f2.Release()
+	if refs := b.refs.Load(); refs != 0 {
+		t.Errorf("unexpected refs after last release: %d", refs)
+	}
+
+	// Simulate the buffer being reused by a new frame. Releasing the old
+	// frames again must not affect it.
+	b.refs.Store(1)
+
+	f1.Release()
+	f2.Release()
+	if refs := b.refs.Load(); refs != 1 {
+		t.Errorf("old frame release affected reused buffer: %d refs", refs)
+	}
+}
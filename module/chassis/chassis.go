@@ -3,6 +3,8 @@ package chassis
 import (
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/brunoga/robomaster/module"
@@ -10,6 +12,7 @@ import (
 	"github.com/brunoga/robomaster/module/internal"
 	"github.com/brunoga/robomaster/module/robot"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
@@ -21,8 +24,39 @@ import (
 // controller interface.
 type Chassis struct {
 	*internal.BaseModule
+
+	gaToken token.Token
+	caToken token.Token
+
+	gimbalAttitude  atomic.Pointer[value.GimbalAttitude]
+	chassisAttitude atomic.Pointer[value.ChassisAttitude]
+
+	frameSpeed atomic.Pointer[frameSpeed]
+
+	m           sync.Mutex
+	frameDriveQ chan struct{}
+	frameDriveD chan struct{}
+
+	// psm serializes SetMotionProfile calls.
+	psm sync.Mutex
+
+	pm            sync.Mutex
+	profiler      *profiler
+	profileTarget [3]float64
+	profileMode   Mode
+	profileQ      chan struct{}
+	profileD      chan struct{}
+	profileN      chan struct{}
+}
+
+type frameSpeed struct {
+	x, y, z float64
 }
 
+// frameDriveInterval is how often speeds are recomputed and sent to the robot
+// while frame driving.
+const frameDriveInterval = 50 * time.Millisecond
+
 var _ module.Module = (*Chassis)(nil)
 
 // New creates a new Chassis instance.
@@ -58,6 +92,29 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 	return c, nil
 }
 
+// Start starts the Chassis module.
+func (c *Chassis) Start() error {
+	var err error
+
+	// Attitude updates are used for frame driving (see StartFrameDrive()).
+	// Note gimbal attitude updates are only sent if the Gimbal module is also
+	// enabled.
+	c.gaToken, err = c.UB().AddKeyListener(key.KeyGimbalAttitude,
+		c.onGimbalAttitude, true)
+	if err != nil {
+		return err
+	}
+
+	c.caToken, err = c.UB().AddKeyListener(key.KeyRobomasterSystemAttitudeInfo,
+		c.onChassisAttitude, true)
+	if err != nil {
+		c.UB().RemoveKeyListener(key.KeyGimbalAttitude, c.gaToken)
+		return err
+	}
+
+	return c.BaseModule.Start()
+}
+
 // SetMode sets the chassis mode for the robot.
 func (c *Chassis) SetMode(m Mode) error {
 	if !m.Valid() {
@@ -77,12 +134,20 @@ func (c *Chassis) SetMode(m Mode) error {
 	return c.control(m, value)
 }
 
-// StopMovement stops the chassis movement.
+// StopMovement stops the chassis movement. This is immediate even if a motion
+// profile is set.
 func (c *Chassis) StopMovement(m Mode) error {
 	if !m.Valid() {
 		return fmt.Errorf("invalid mode: %d", m)
 	}
 
+	c.pm.Lock()
+	if c.profiler != nil {
+		c.profileTarget = [3]float64{}
+		c.profiler.reset([3]float64{})
+	}
+	c.pm.Unlock()
+
 	// TODO(bga): Figure out this value.
 	value := uint64(0) | uint64(140) | uint64(17920) | uint64(235929600)
 
@@ -90,19 +155,188 @@ func (c *Chassis) StopMovement(m Mode) error {
 }
 
 // SetSpeed sets the chassis speed. Limits are [-3.5, 3.5] (m/s) for x and y and
-// [-360, 360] (degrees/s) for z.
+// [-360, 360] (degrees/s) for z. If a motion profile is set (see
+// SetMotionProfile()), the speed will change gradually according to it and
+// this returns immediately.
 func (c *Chassis) SetSpeed(m Mode, x, y, z float64) error {
+	if !m.Valid() {
+		return fmt.Errorf("invalid mode: %d", m)
+	}
+
 	if x > 3.5 || x < -3.5 || y > 3.5 || y < -3.5 || z > 360 || z < -360 {
 		return fmt.Errorf("invalid speed values: x=%f, y=%f, z=%f", x, y, z)
 	}
 
-	xComponent := (int64(x*10) + 35) << 2
-	yComponent := (int64(y*10) + 35) << 9
-	zComponent := (int64(z*10) + 3600) << 16
+	c.pm.Lock()
+	if c.profiler != nil {
+		c.profileTarget = [3]float64{x, y, z}
+		c.profileMode = m
+		c.pm.Unlock()
 
-	value := uint64(1 | xComponent | yComponent | zComponent)
+		// Wake up the profile loop if needed.
+		select {
+		case c.profileN <- struct{}{}:
+		default:
+		}
 
-	return c.control(m, value)
+		return nil
+	}
+	c.pm.Unlock()
+
+	return c.control(m, speedValue(x, y, z))
+}
+
+// SetMotionProfile sets the motion profile to be used by SetSpeed (and
+// everything built on top of it, like frame driving). Passing nil disables
+// motion profiles and speeds will, again, be set immediately. The profile
+// starts from a stopped chassis.
+func (c *Chassis) SetMotionProfile(p *MotionProfile) error {
+	if p != nil {
+		err := p.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	c.psm.Lock()
+	defer c.psm.Unlock()
+
+	c.pm.Lock()
+	quit, done := c.profileQ, c.profileD
+	c.profiler = nil
+	c.profileQ = nil
+	c.profileD = nil
+	c.profileN = nil
+	c.pm.Unlock()
+
+	// The profile loop needs pm, so it must not be held while waiting for it
+	// to exit.
+	if quit != nil {
+		close(quit)
+		<-done
+	}
+
+	if p == nil {
+		return nil
+	}
+
+	pr := newProfiler(*p)
+
+	c.pm.Lock()
+	defer c.pm.Unlock()
+
+	c.profiler = pr
+	c.profileTarget = [3]float64{}
+	c.profileQ = make(chan struct{})
+	c.profileD = make(chan struct{})
+	c.profileN = make(chan struct{}, 1)
+
+	go c.profileLoop(pr, p.interval(), c.profileQ, c.profileD, c.profileN)
+
+	return nil
+}
+
+// SetSpeedInFrame is like SetSpeed but x and y are expressed in the given
+// frame instead of being relative to the chassis. The conversion uses the
+// latest known gimbal or chassis yaw, so it is only valid until either of them
+// changes. Use StartFrameDrive() to have speeds continuously updated.
+func (c *Chassis) SetSpeedInFrame(m Mode, f Frame, x, y, z float64) error {
+	if x > 3.5 || x < -3.5 || y > 3.5 || y < -3.5 || z > 360 || z < -360 {
+		return fmt.Errorf("invalid speed values: x=%f, y=%f, z=%f", x, y, z)
+	}
+
+	yaw, err := c.frameYaw(f)
+	if err != nil {
+		return err
+	}
+
+	x, y = rotate(x, y, yaw)
+
+	// Rotation preserves the speed magnitude but not the individual
+	// components, so they might now be out of range. Scale them together so
+	// the direction is preserved.
+	x, y = scale(x, y, 3.5)
+
+	return c.SetSpeed(m, x, y, z)
+}
+
+// StartFrameDrive starts driving the chassis with speeds expressed in the
+// given frame. Speeds are set with SetFrameSpeed() and are continuously
+// converted to chassis speeds using live gimbal and chassis attitude updates,
+// so, for example, with FrameGimbal, moving forward will always follow the
+// camera direction even if the gimbal rotates independently of the chassis.
+// The robot starts stopped.
+func (c *Chassis) StartFrameDrive(m Mode, f Frame) error {
+	if !m.Valid() {
+		return fmt.Errorf("invalid mode: %d", m)
+	}
+
+	if !f.Valid() {
+		return fmt.Errorf("invalid frame: %d", f)
+	}
+
+	// Fail early if we do not have the data we need for this frame.
+	_, err := c.frameYaw(f)
+	if err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.frameDriveQ != nil {
+		return fmt.Errorf("frame drive already started")
+	}
+
+	c.frameSpeed.Store(&frameSpeed{})
+
+	c.frameDriveQ = make(chan struct{})
+	c.frameDriveD = make(chan struct{})
+
+	go c.frameDriveLoop(m, f, c.frameDriveQ, c.frameDriveD)
+
+	return nil
+}
+
+// SetFrameSpeed sets the speeds to be used while frame driving. Limits are the
+// same as for SetSpeed.
+func (c *Chassis) SetFrameSpeed(x, y, z float64) error {
+	if x > 3.5 || x < -3.5 || y > 3.5 || y < -3.5 || z > 360 || z < -360 {
+		return fmt.Errorf("invalid speed values: x=%f, y=%f, z=%f", x, y, z)
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.frameDriveQ == nil {
+		return fmt.Errorf("frame drive not started")
+	}
+
+	c.frameSpeed.Store(&frameSpeed{x, y, z})
+
+	return nil
+}
+
+// StopFrameDrive stops frame driving and stops the chassis movement.
+func (c *Chassis) StopFrameDrive() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.stopFrameDriveLocked()
+}
+
+func (c *Chassis) stopFrameDriveLocked() error {
+	if c.frameDriveQ == nil {
+		return fmt.Errorf("frame drive not started")
+	}
+
+	close(c.frameDriveQ)
+	<-c.frameDriveD
+
+	c.frameDriveQ = nil
+	c.frameDriveD = nil
+
+	return nil
 }
 
 // SetPosition sets the chassis position.
@@ -124,6 +358,175 @@ func (c *Chassis) SetPosition(m Mode, x, y, z float64) error {
 	})
 }
 
+// Stop stops the Chassis module.
+func (c *Chassis) Stop() error {
+	c.m.Lock()
+	if c.frameDriveQ != nil {
+		err := c.stopFrameDriveLocked()
+		if err != nil {
+			c.m.Unlock()
+			return err
+		}
+	}
+	c.m.Unlock()
+
+	err := c.SetMotionProfile(nil)
+	if err != nil {
+		return err
+	}
+
+	err = c.UB().RemoveKeyListener(key.KeyGimbalAttitude, c.gaToken)
+	if err != nil {
+		return err
+	}
+
+	err = c.UB().RemoveKeyListener(key.KeyRobomasterSystemAttitudeInfo,
+		c.caToken)
+	if err != nil {
+		return err
+	}
+
+	return c.BaseModule.Stop()
+}
+
+func (c *Chassis) frameDriveLoop(m Mode, f Frame, quit <-chan struct{},
+	done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(frameDriveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			err := c.StopMovement(m)
+			if err != nil {
+				c.Logger().Error("Frame drive: Failed to stop movement.",
+					"error", err)
+			}
+			return
+		case <-ticker.C:
+			fs := c.frameSpeed.Load()
+
+			err := c.SetSpeedInFrame(m, f, fs.x, fs.y, fs.z)
+			if err != nil {
+				c.Logger().Error("Frame drive: Failed to set speed.", "error",
+					err)
+			}
+		}
+	}
+}
+
+func (c *Chassis) profileLoop(pr *profiler, interval time.Duration,
+	quit <-chan struct{}, done chan<- struct{}, notify <-chan struct{}) {
+	defer close(done)
+
+	var ticker *time.Ticker
+	var tickerC <-chan time.Time
+
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	dt := interval.Seconds()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-notify:
+			if ticker == nil {
+				ticker = time.NewTicker(interval)
+				tickerC = ticker.C
+			}
+		case <-tickerC:
+			c.pm.Lock()
+			v, reached := pr.step(c.profileTarget, dt)
+			m := c.profileMode
+			c.pm.Unlock()
+
+			err := c.control(m, speedValue(v[0], v[1], v[2]))
+			if err != nil {
+				c.Logger().Error("Motion profile: Failed to set speed.",
+					"error", err)
+			}
+
+			if reached {
+				ticker.Stop()
+				ticker = nil
+				tickerC = nil
+			}
+		}
+	}
+}
+
+// frameYaw returns the angle (in degrees) that must be used to rotate speeds
+// in the given frame to speeds relative to the chassis.
+func (c *Chassis) frameYaw(f Frame) (float64, error) {
+	switch f {
+	case FrameBody:
+		return 0, nil
+	case FrameGimbal:
+		ga := c.gimbalAttitude.Load()
+		if ga == nil {
+			return 0, fmt.Errorf("no gimbal attitude available. Is the " +
+				"gimbal module enabled?")
+		}
+
+		// Gimbal yaw is relative to the chassis.
+		return float64(ga.Yaw), nil
+	case FrameWorld:
+		ca := c.chassisAttitude.Load()
+		if ca == nil {
+			return 0, fmt.Errorf("no chassis attitude available")
+		}
+
+		return -float64(ca.Yaw), nil
+	}
+
+	return 0, fmt.Errorf("invalid frame: %d", f)
+}
+
+func (c *Chassis) onGimbalAttitude(r *result.Result) {
+	if r == nil || !r.Succeeded() {
+		return
+	}
+
+	ga, ok := r.Value().(*value.GimbalAttitude)
+	if !ok {
+		c.Logger().Error("Unexpected gimbal attitude value.", "value",
+			r.Value())
+		return
+	}
+
+	c.gimbalAttitude.Store(ga)
+}
+
+func (c *Chassis) onChassisAttitude(r *result.Result) {
+	if r == nil || !r.Succeeded() {
+		return
+	}
+
+	ca, ok := r.Value().(*value.ChassisAttitude)
+	if !ok {
+		c.Logger().Error("Unexpected chassis attitude value.", "value",
+			r.Value())
+		return
+	}
+
+	c.chassisAttitude.Store(ca)
+}
+
+func speedValue(x, y, z float64) uint64 {
+	xComponent := (int64(x*10) + 35) << 2
+	yComponent := (int64(y*10) + 35) << 9
+	zComponent := (int64(z*10) + 3600) << 16
+
+	return uint64(1 | xComponent | yComponent | zComponent)
+}
+
 func (c *Chassis) control(m Mode, value uint64) error {
 	if !m.Valid() {
 		return fmt.Errorf("invalid mode: %d", m)
@@ -0,0 +1,108 @@
+package chassis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/mock"
+)
+
+// sentSpeeds returns the number of speeds sent to the robot.
+func sentSpeeds(uw *mock.UnityBridge) int {
+	code := event.NewFromTypeAndSubType(event.TypePerformAction,
+		key.KeyMainControllerChassisSpeedMode.SubType()).Code()
+
+	n := 0
+	for _, e := range uw.Sent() {
+		if e.Code == code {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestSetMotionProfileWhileRunning(t *testing.T) {
+	uw, ub := mock.NewStartedUnityBridge(t)
+
+	c, err := New(ub, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &MotionProfile{
+		Type:            ProfileTypeTrapezoidal,
+		MaxAcceleration: Axes{X: 0.1, Y: 0.1, Z: 1},
+		Interval:        time.Millisecond,
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			err := c.SetMotionProfile(p)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			// Start the profile loop ticker. The target is far enough that
+			// it is still running when the profile is changed.
+			err = c.SetSpeed(ModeFPV, 3, 3, 360)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		err := c.SetMotionProfile(nil)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("deadlock changing motion profile")
+	}
+
+	if sentSpeeds(uw) == 0 {
+		t.Error("no speeds sent by the profile loop")
+	}
+}
+
+func TestSetSpeedInvalidMode(t *testing.T) {
+	uw, ub := mock.NewStartedUnityBridge(t)
+
+	c, err := New(ub, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SetMotionProfile(&MotionProfile{
+		Type:            ProfileTypeTrapezoidal,
+		MaxAcceleration: Axes{X: 1, Y: 1, Z: 90},
+		Interval:        time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.SetMotionProfile(nil)
+
+	if c.SetSpeed(modeCount, 1, 0, 0) == nil {
+		t.Error("expected error setting speed with invalid mode")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if n := sentSpeeds(uw); n != 0 {
+		t.Errorf("got %d speeds sent for invalid mode, want 0", n)
+	}
+}
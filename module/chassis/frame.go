@@ -0,0 +1,65 @@
+package chassis
+
+import (
+	"fmt"
+	"math"
+)
+
+// Frame is the reference frame speeds are expressed in when using frame
+// driving (see StartFrameDrive()).
+type Frame uint8
+
+const (
+	// FrameBody means speeds are relative to the chassis itself. This is the
+	// same as using SetSpeed() directly.
+	FrameBody Frame = iota
+	// FrameGimbal means speeds are relative to where the gimbal is pointing
+	// to (i.e. x is always the direction the camera is looking at).
+	FrameGimbal
+	// FrameWorld means speeds are relative to the chassis heading when the
+	// robot was turned on (i.e. x is always the same direction in the world,
+	// independently of where the chassis is pointing to).
+	FrameWorld
+	// frameCount is the number of frames. Intentionaly not exported.
+	frameCount
+)
+
+func (f Frame) String() string {
+	switch f {
+	case FrameBody:
+		return "Body"
+	case FrameGimbal:
+		return "Gimbal"
+	case FrameWorld:
+		return "World"
+	default:
+		return fmt.Sprintf("Unknown(%d)", f)
+	}
+}
+
+func (f Frame) Valid() bool {
+	return f < frameCount
+}
+
+// rotate rotates the given x and y components by the given yaw (in degrees).
+// Yaw is positive clockwise, x points forward and y points to the right, which
+// matches what the robot uses.
+func rotate(x, y, yaw float64) (float64, float64) {
+	sin, cos := math.Sincos(yaw * math.Pi / 180)
+
+	return x*cos - y*sin, x*sin + y*cos
+}
+
+// scale scales the given x and y components by the same factor so neither of
+// them is above the given limit (in absolute value). This preserves the
+// direction of the vector.
+func scale(x, y, limit float64) (float64, float64) {
+	m := math.Max(math.Abs(x), math.Abs(y))
+	if m <= limit {
+		return x, y
+	}
+
+	f := limit / m
+
+	return x * f, y * f
+}
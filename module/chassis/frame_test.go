@@ -0,0 +1,51 @@
+package chassis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		x, y, yaw            float64
+		expectedX, expectedY float64
+	}{
+		{1, 0, 0, 1, 0},
+		{1, 0, 90, 0, 1},
+		{1, 0, -90, 0, -1},
+		{0, 1, 90, -1, 0},
+		{1, 1, 180, -1, -1},
+	}
+
+	for _, test := range tests {
+		x, y := rotate(test.x, test.y, test.yaw)
+		if math.Abs(x-test.expectedX) > 1e-9 ||
+			math.Abs(y-test.expectedY) > 1e-9 {
+			t.Errorf("rotate(%f, %f, %f) = (%f, %f), expected (%f, %f)",
+				test.x, test.y, test.yaw, x, y, test.expectedX,
+				test.expectedY)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		x, y, limit          float64
+		expectedX, expectedY float64
+	}{
+		{1, 2, 3.5, 1, 2},
+		{4.9, 0, 3.5, 3.5, 0},
+		{4.9, 4.9, 3.5, 3.5, 3.5},
+		{-7, 3.5, 3.5, -3.5, 1.75},
+	}
+
+	for _, test := range tests {
+		x, y := scale(test.x, test.y, test.limit)
+		if math.Abs(x-test.expectedX) > 1e-9 ||
+			math.Abs(y-test.expectedY) > 1e-9 {
+			t.Errorf("scale(%f, %f, %f) = (%f, %f), expected (%f, %f)",
+				test.x, test.y, test.limit, x, y, test.expectedX,
+				test.expectedY)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package chassis
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ProfileType is the type of motion profile used when changing chassis speeds.
+type ProfileType uint8
+
+const (
+	// ProfileTypeTrapezoidal limits acceleration, so speeds change linearly
+	// over time.
+	ProfileTypeTrapezoidal ProfileType = iota
+	// ProfileTypeSCurve limits acceleration and jerk, so acceleration itself
+	// changes linearly over time. This is smoother than trapezoidal profiles
+	// at the expense of taking longer to reach the target speed.
+	ProfileTypeSCurve
+	// profileTypeCount is the number of profile types. Intentionaly not
+	// exported.
+	profileTypeCount
+)
+
+func (p ProfileType) String() string {
+	switch p {
+	case ProfileTypeTrapezoidal:
+		return "Trapezoidal"
+	case ProfileTypeSCurve:
+		return "SCurve"
+	default:
+		return fmt.Sprintf("Unknown(%d)", p)
+	}
+}
+
+func (p ProfileType) Valid() bool {
+	return p < profileTypeCount
+}
+
+// Axes holds per-axis values. X and Y are in m/s (or derivatives) and Z is in
+// degrees/s (or derivatives).
+type Axes struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+func (a Axes) array() [3]float64 {
+	return [3]float64{a.X, a.Y, a.Z}
+}
+
+// MotionProfile describes how the chassis should go from its current speed to
+// a new target speed.
+type MotionProfile struct {
+	// Type is the type of profile to use.
+	Type ProfileType
+
+	// MaxAcceleration is the maximum acceleration per axis (m/s^2 for x and y
+	// and degrees/s^2 for z). All values must be positive.
+	MaxAcceleration Axes
+
+	// MaxJerk is the maximum jerk per axis (m/s^3 for x and y and
+	// degrees/s^3 for z). Only used for S-curve profiles, in which case all
+	// values must be positive.
+	MaxJerk Axes
+
+	// Interval is how often intermediate speeds are sent to the robot. If
+	// zero, defaults to 50ms.
+	Interval time.Duration
+}
+
+// Validate returns a non-nil error if the profile is not valid.
+func (p *MotionProfile) Validate() error {
+	if !p.Type.Valid() {
+		return fmt.Errorf("invalid profile type: %d", p.Type)
+	}
+
+	for _, a := range p.MaxAcceleration.array() {
+		if a <= 0 {
+			return fmt.Errorf("invalid max acceleration: %+v",
+				p.MaxAcceleration)
+		}
+	}
+
+	if p.Type == ProfileTypeSCurve {
+		for _, j := range p.MaxJerk.array() {
+			if j <= 0 {
+				return fmt.Errorf("invalid max jerk: %+v", p.MaxJerk)
+			}
+		}
+	}
+
+	if p.Interval < 0 {
+		return fmt.Errorf("invalid interval: %s", p.Interval)
+	}
+
+	return nil
+}
+
+func (p *MotionProfile) interval() time.Duration {
+	if p.Interval == 0 {
+		return 50 * time.Millisecond
+	}
+
+	return p.Interval
+}
+
+// profiler keeps track of the profiled speed and acceleration per axis.
+type profiler struct {
+	p MotionProfile
+
+	v [3]float64
+	a [3]float64
+}
+
+func newProfiler(p MotionProfile) *profiler {
+	return &profiler{
+		p: p,
+	}
+}
+
+// step advances the profile by dt seconds towards the given target speeds and
+// returns the new speeds. done is true when all axis reached their targets.
+func (pr *profiler) step(target [3]float64, dt float64) (v [3]float64,
+	done bool) {
+	maxAcceleration := pr.p.MaxAcceleration.array()
+	maxJerk := pr.p.MaxJerk.array()
+
+	done = true
+	for i := range target {
+		switch pr.p.Type {
+		case ProfileTypeTrapezoidal:
+			pr.v[i] = stepTrapezoidal(pr.v[i], target[i], maxAcceleration[i],
+				dt)
+		case ProfileTypeSCurve:
+			pr.v[i], pr.a[i] = stepSCurve(pr.v[i], pr.a[i], target[i],
+				maxAcceleration[i], maxJerk[i], dt)
+		}
+
+		if pr.v[i] != target[i] {
+			done = false
+		}
+	}
+
+	return pr.v, done
+}
+
+// reset sets the current speeds (for when the chassis speed was changed
+// without going through the profiler).
+func (pr *profiler) reset(v [3]float64) {
+	pr.v = v
+	pr.a = [3]float64{}
+}
+
+func stepTrapezoidal(v, target, maxAcceleration, dt float64) float64 {
+	maxDelta := maxAcceleration * dt
+
+	delta := target - v
+	if math.Abs(delta) <= maxDelta {
+		return target
+	}
+
+	return v + math.Copysign(maxDelta, delta)
+}
+
+func stepSCurve(v, a, target, maxAcceleration, maxJerk,
+	dt float64) (float64, float64) {
+	delta := target - v
+
+	maxJerkDelta := maxJerk * dt
+
+	// Close enough to just snap into the target.
+	if math.Abs(delta) <= math.Abs(a)*dt+maxJerkDelta*dt &&
+		math.Abs(a) <= maxJerkDelta {
+		return target, 0
+	}
+
+	// Speed change that would happen if we started bringing acceleration to
+	// zero right now.
+	brakingDelta := a * math.Abs(a) / (2 * maxJerk)
+
+	// Decide if we should increase or decrease acceleration.
+	if delta-brakingDelta > 0 {
+		a = math.Min(a+maxJerkDelta, maxAcceleration)
+	} else {
+		a = math.Max(a-maxJerkDelta, -maxAcceleration)
+	}
+
+	newV := v + a*dt
+
+	// Do not overshoot.
+	if (delta > 0 && newV > target) || (delta < 0 && newV < target) {
+		return target, 0
+	}
+
+	return newV, a
+}
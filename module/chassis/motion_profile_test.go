@@ -0,0 +1,91 @@
+package chassis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProfilerTrapezoidal(t *testing.T) {
+	pr := newProfiler(MotionProfile{
+		Type:            ProfileTypeTrapezoidal,
+		MaxAcceleration: Axes{X: 1, Y: 1, Z: 90},
+	})
+
+	target := [3]float64{1, -0.5, 90}
+
+	steps := 0
+	for {
+		v, done := pr.step(target, 0.125)
+		steps++
+
+		if v[0] > 1 || v[1] < -0.5 || v[2] > 90 {
+			t.Fatalf("overshoot: %v", v)
+		}
+
+		if done {
+			break
+		}
+
+		if steps > 100 {
+			t.Fatalf("target not reached")
+		}
+	}
+
+	// Slowest axes need 1s at 0.125s per step.
+	if steps != 8 {
+		t.Errorf("expected 8 steps, got %d", steps)
+	}
+}
+
+func TestProfilerSCurve(t *testing.T) {
+	pr := newProfiler(MotionProfile{
+		Type:            ProfileTypeSCurve,
+		MaxAcceleration: Axes{X: 1, Y: 1, Z: 90},
+		MaxJerk:         Axes{X: 2, Y: 2, Z: 180},
+	})
+
+	target := [3]float64{1, 0, -90}
+
+	lastV := [3]float64{}
+	for steps := 0; ; steps++ {
+		v, done := pr.step(target, 0.01)
+
+		for i := range v {
+			a := (v[i] - lastV[i]) / 0.01
+			limit := []float64{1, 1, 90}[i]
+			if math.Abs(a) > limit+1e-9 {
+				t.Fatalf("acceleration limit exceeded on axis %d: %f", i, a)
+			}
+		}
+
+		lastV = v
+
+		if done {
+			break
+		}
+
+		if steps > 10000 {
+			t.Fatalf("target not reached: %v", v)
+		}
+	}
+
+	if lastV != target {
+		t.Errorf("expected %v, got %v", target, lastV)
+	}
+}
+
+func TestMotionProfileValidate(t *testing.T) {
+	p := &MotionProfile{
+		Type:            ProfileTypeSCurve,
+		MaxAcceleration: Axes{X: 1, Y: 1, Z: 90},
+	}
+
+	if p.Validate() == nil {
+		t.Errorf("expected error for S-curve profile without jerk limits")
+	}
+
+	p.Type = ProfileTypeTrapezoidal
+	if err := p.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -1,15 +1,19 @@
 package connection
 
 import (
+	"fmt"
 	"log/slog"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/internal"
+	"github.com/brunoga/robomaster/support/delivery"
 	"github.com/brunoga/robomaster/support/finder"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/unity/event"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
@@ -25,6 +29,9 @@ const (
 	subTypeConnectionSetPort
 
 	wifiDirectIPString = "192.168.2.1"
+	usbIPString        = "192.168.42.2"
+
+	robotPort = 10607
 )
 
 // Connection provides support for managing the connection to the robot.
@@ -33,20 +40,47 @@ type Connection struct {
 
 	appID uint64
 	typ   Type
+	ip    net.IP
 
 	f *finder.Finder
 
 	signalQuality atomic.Uint64
 
 	signalQualityRL *listener.Listener
+
+	lhm sync.Mutex
+	lh  *linkHealth
+
+	callbacks *delivery.Callbacks[LinkEvent]
 }
 
 var _ module.Module = (*Connection)(nil)
 
 // New creates a new Connection instance with the given UnityBridge instance and
-// logger.
+// logger. TypeStaticIP connections must be created with NewWithIP instead.
 func New(ub unitybridge.UnityBridge,
 	l *logger.Logger, appID uint64, typ Type) (*Connection, error) {
+	if typ == TypeStaticIP {
+		return nil, fmt.Errorf("static IP connections require an IP")
+	}
+
+	return newConnection(ub, l, appID, typ, nil)
+}
+
+// NewWithIP creates a new Connection instance that connects directly to the
+// robot at the given IP (without relying on broadcast discovery, which is
+// useful in networks that block broadcasts).
+func NewWithIP(ub unitybridge.UnityBridge, l *logger.Logger,
+	ip net.IP) (*Connection, error) {
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("invalid robot IP: %v", ip)
+	}
+
+	return newConnection(ub, l, 0, TypeStaticIP, ip)
+}
+
+func newConnection(ub unitybridge.UnityBridge, l *logger.Logger, appID uint64,
+	typ Type, ip net.IP) (*Connection, error) {
 	if l == nil {
 		l = logger.New(slog.LevelError)
 	}
@@ -54,10 +88,23 @@ func New(ub unitybridge.UnityBridge,
 	l = l.WithGroup("connection_module").With(
 		slog.Uint64("app_id", appID))
 
+	switch typ {
+	case TypeWiFiDirect:
+		ip = net.ParseIP(wifiDirectIPString)
+	case TypeUSB:
+		ip = net.ParseIP(usbIPString)
+	case TypeRouter, TypeStaticIP:
+	default:
+		return nil, fmt.Errorf("invalid connection type: %d", typ)
+	}
+
 	c := &Connection{
-		appID: appID,
-		typ:   typ,
-		f:     finder.New(appID, l),
+		appID:     appID,
+		typ:       typ,
+		ip:        ip,
+		f:         finder.New(appID, l),
+		lh:        newLinkHealth(signalQualityHistorySize, DefaultLinkHealthThresholds),
+		callbacks: delivery.NewCallbacks[LinkEvent](),
 	}
 
 	c.BaseModule = internal.NewBaseModule(ub, l, "Connection",
@@ -85,6 +132,8 @@ func New(ub unitybridge.UnityBridge,
 						"Connection: Failed to start signal quality listener.",
 						"error", err)
 				}
+
+				c.onConnectionChanged(true)
 			} else {
 				// Connection is down. Stop listeners.
 				c.Logger().Debug(
@@ -95,6 +144,8 @@ func New(ub unitybridge.UnityBridge,
 						"Connection: Failed to stop signal quality listener.",
 						"error", err)
 				}
+
+				c.onConnectionChanged(false)
 			}
 		})
 
@@ -114,7 +165,7 @@ func (c *Connection) Start() error {
 		return err
 	}
 
-	var ip net.IP = net.ParseIP(wifiDirectIPString)
+	ip := c.ip
 	if c.typ == TypeRouter {
 		b, err := c.f.Find(30 * time.Second)
 		if err != nil {
@@ -141,7 +192,7 @@ func (c *Connection) Start() error {
 	}
 
 	e.ResetSubType(subTypeConnectionSetPort)
-	err = c.UB().SendEventWithUint64(e, 10607)
+	err = c.UB().SendEventWithUint64(e, robotPort)
 	if err != nil {
 		return err
 	}
@@ -155,6 +206,11 @@ func (c *Connection) Start() error {
 	return nil
 }
 
+// Type returns the connection type.
+func (c *Connection) Type() Type {
+	return c.typ
+}
+
 // SignalQualityLevel returns the current signal quality level. 0 means no
 // signal whatsoever and 60 appears to be the strongest value.
 func (c *Connection) SignalQualityLevel() uint8 {
@@ -179,6 +235,75 @@ func (c *Connection) SignalQualityBars() uint8 {
 	return 4
 }
 
+// SignalQualityHistory returns all signal quality samples received at or after
+// the given time, oldest first. Only a bounded number of samples is kept.
+func (c *Connection) SignalQualityHistory(since time.Time) []SignalQualitySample {
+	c.lhm.Lock()
+	defer c.lhm.Unlock()
+
+	return c.lh.since(since)
+}
+
+// SignalQualityAverage returns the average signal quality level over the given
+// time window (ending now). Returns 0 if there are no samples in the window.
+func (c *Connection) SignalQualityAverage(window time.Duration) float64 {
+	c.lhm.Lock()
+	defer c.lhm.Unlock()
+
+	return c.lh.average(window, time.Now())
+}
+
+// LinkDegraded returns true if the link is currently considered degraded
+// according to the link health thresholds.
+func (c *Connection) LinkDegraded() bool {
+	c.lhm.Lock()
+	defer c.lhm.Unlock()
+
+	return c.lh.degraded
+}
+
+// SetLinkHealthThresholds sets the thresholds used to generate link degraded
+// and recovered events.
+func (c *Connection) SetLinkHealthThresholds(lht LinkHealthThresholds) error {
+	err := lht.Validate()
+	if err != nil {
+		return err
+	}
+
+	c.lhm.Lock()
+	defer c.lhm.Unlock()
+
+	c.lh.thresholds = lht
+
+	return nil
+}
+
+// AddLinkEventCallback adds a callback function to be called for link health
+// events (connection established or lost and link degraded or recovered). The
+// callback function will be called in a separate goroutine, with events
+// delivered in the order they happened. Returns a token that can be used to
+// remove the callback later.
+func (c *Connection) AddLinkEventCallback(cb LinkEventCallback) (token.Token,
+	error) {
+	if cb == nil {
+		return 0, fmt.Errorf("callback must not be nil")
+	}
+
+	return c.callbacks.Add(cb), nil
+}
+
+// RemoveLinkEventCallback removes the link event callback associated with the
+// given token. Once this returns, the callback gets no further link events
+// (including ones that happened before it was removed but were not delivered
+// yet).
+func (c *Connection) RemoveLinkEventCallback(t token.Token) error {
+	if !c.callbacks.Remove(t) {
+		return fmt.Errorf("no link event callback added for token %d", t)
+	}
+
+	return nil
+}
+
 // Stop stops the connection module.
 func (cm *Connection) Stop() error {
 	e := event.NewFromType(event.TypeConnection)
@@ -207,4 +332,46 @@ func (c *Connection) onSignalQuality(r *result.Result) {
 	}
 
 	c.signalQuality.Store(value.Value)
+
+	c.lhm.Lock()
+	defer c.lhm.Unlock()
+
+	e, ok := c.lh.add(SignalQualitySample{
+		Level: uint8(value.Value),
+		Time:  time.Now(),
+	})
+	if ok {
+		c.notifyLinkEventLocked(e)
+	}
+}
+
+func (c *Connection) onConnectionChanged(connected bool) {
+	now := time.Now()
+
+	c.lhm.Lock()
+	defer c.lhm.Unlock()
+
+	c.lh.reset()
+
+	e := LinkEvent{
+		Type:    LinkEventTypeDisconnected,
+		Level:   c.SignalQualityLevel(),
+		Average: c.lh.average(c.lh.thresholds.Window, now),
+		Time:    now,
+	}
+	if connected {
+		e.Type = LinkEventTypeConnected
+	}
+
+	c.notifyLinkEventLocked(e)
+}
+
+// notifyLinkEventLocked queues the given event for all link event callbacks.
+// Must be called with lhm held so events are queued in the order they
+// happened.
+func (c *Connection) notifyLinkEventLocked(e LinkEvent) {
+	c.Logger().Debug("Connection: Link event.", "type", e.Type, "level",
+		e.Level, "average", e.Average)
+
+	c.callbacks.Push(e)
 }
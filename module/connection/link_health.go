@@ -0,0 +1,194 @@
+package connection
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster/support/history"
+)
+
+// signalQualityHistorySize is the number of signal quality samples kept in the
+// signal quality history. Updates are sent by the robot around once per
+// second so this should cover the last 10 minutes or so.
+const signalQualityHistorySize = 600
+
+// SignalQualitySample is a signal quality level and the time it was received.
+type SignalQualitySample struct {
+	Level uint8
+	Time  time.Time
+}
+
+// LinkEventType is the type of a link health event.
+type LinkEventType uint8
+
+const (
+	// LinkEventTypeConnected is sent when the connection to the robot is
+	// established.
+	LinkEventTypeConnected LinkEventType = iota
+
+	// LinkEventTypeDisconnected is sent when the connection to the robot is
+	// lost.
+	LinkEventTypeDisconnected
+
+	// LinkEventTypeDegraded is sent when the average signal quality drops to
+	// or below the degraded threshold.
+	LinkEventTypeDegraded
+
+	// LinkEventTypeRecovered is sent when the average signal quality goes
+	// back to or above the recovered threshold after being degraded.
+	LinkEventTypeRecovered
+
+	LinkEventTypeCount
+)
+
+// String returns the link event type as a string.
+func (let LinkEventType) String() string {
+	switch let {
+	case LinkEventTypeConnected:
+		return "Connected"
+	case LinkEventTypeDisconnected:
+		return "Disconnected"
+	case LinkEventTypeDegraded:
+		return "Degraded"
+	case LinkEventTypeRecovered:
+		return "Recovered"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the link event type is valid.
+func (let LinkEventType) Valid() bool {
+	return let < LinkEventTypeCount
+}
+
+// LinkEvent is a link health event.
+type LinkEvent struct {
+	Type LinkEventType
+
+	// Level is the last signal quality level received.
+	Level uint8
+
+	// Average is the average signal quality level over the configured
+	// window.
+	Average float64
+
+	// Time is the time the event happened.
+	Time time.Time
+}
+
+// LinkEventCallback is the type of the callback function used to receive link
+// health events.
+type LinkEventCallback func(e LinkEvent)
+
+// LinkHealthThresholds configures when link health events are generated.
+type LinkHealthThresholds struct {
+	// Degraded is the average signal quality level at or below which the link
+	// is considered degraded.
+	Degraded uint8
+
+	// Recovered is the average signal quality level at or above which a
+	// degraded link is considered recovered. It must be higher than Degraded
+	// to avoid flapping.
+	Recovered uint8
+
+	// Window is the time window used for averaging signal quality samples.
+	Window time.Duration
+}
+
+// DefaultLinkHealthThresholds are the default link health thresholds.
+var DefaultLinkHealthThresholds = LinkHealthThresholds{
+	Degraded:  10,
+	Recovered: 20,
+	Window:    3 * time.Second,
+}
+
+// Validate returns an error if the thresholds are not valid.
+func (lht LinkHealthThresholds) Validate() error {
+	if lht.Degraded >= lht.Recovered {
+		return fmt.Errorf("degraded threshold (%d) must be lower than "+
+			"recovered threshold (%d)", lht.Degraded, lht.Recovered)
+	}
+
+	if lht.Window <= 0 {
+		return fmt.Errorf("invalid window: %s", lht.Window)
+	}
+
+	return nil
+}
+
+// linkHealth keeps a bounded history of signal quality samples and tracks
+// link health. It is not thread safe.
+type linkHealth struct {
+	thresholds LinkHealthThresholds
+
+	h *history.History[SignalQualitySample]
+
+	degraded bool
+}
+
+func newLinkHealth(size int, thresholds LinkHealthThresholds) *linkHealth {
+	return &linkHealth{
+		thresholds: thresholds,
+		h: history.New(size, func(s SignalQualitySample) time.Time {
+			return s.Time
+		}),
+	}
+}
+
+// add adds the given sample to the history and returns a link event if the
+// link health changed.
+func (lh *linkHealth) add(s SignalQualitySample) (LinkEvent, bool) {
+	if !lh.h.Add(s) {
+		// Out of order sample.
+		return LinkEvent{}, false
+	}
+
+	average := lh.average(lh.thresholds.Window, s.Time)
+
+	e := LinkEvent{
+		Level:   s.Level,
+		Average: average,
+		Time:    s.Time,
+	}
+
+	if !lh.degraded && average <= float64(lh.thresholds.Degraded) {
+		lh.degraded = true
+		e.Type = LinkEventTypeDegraded
+		return e, true
+	}
+
+	if lh.degraded && average >= float64(lh.thresholds.Recovered) {
+		lh.degraded = false
+		e.Type = LinkEventTypeRecovered
+		return e, true
+	}
+
+	return LinkEvent{}, false
+}
+
+// average returns the average level of samples in the given window ending at
+// the given time. Returns 0 if there are no samples in the window.
+func (lh *linkHealth) average(window time.Duration, now time.Time) float64 {
+	samples := lh.since(now.Add(-window))
+	if len(samples) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, s := range samples {
+		sum += float64(s.Level)
+	}
+
+	return sum / float64(len(samples))
+}
+
+// since returns all samples at or after the given time, oldest first.
+func (lh *linkHealth) since(t time.Time) []SignalQualitySample {
+	return lh.h.Since(t)
+}
+
+// reset resets the link health state (but keeps the history).
+func (lh *linkHealth) reset() {
+	lh.degraded = false
+}
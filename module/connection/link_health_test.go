@@ -0,0 +1,82 @@
+package connection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinkHealthEvents(t *testing.T) {
+	lh := newLinkHealth(10, LinkHealthThresholds{
+		Degraded:  10,
+		Recovered: 20,
+		Window:    2 * time.Second,
+	})
+
+	start := time.Now()
+
+	tests := []struct {
+		level  uint8
+		want   LinkEventType
+		wantOk bool
+	}{
+		{30, 0, false},
+		{30, 0, false},
+		{5, 0, false},                      // avg(30, 30, 5) = 21.7
+		{5, 0, false},                      // avg(30, 5, 5) = 13.3
+		{15, LinkEventTypeDegraded, true},  // avg(5, 5, 15) = 8.3
+		{25, 0, false},                     // avg(5, 15, 25) = 15
+		{30, LinkEventTypeRecovered, true}, // avg(15, 25, 30) = 23.3
+		{30, 0, false},
+	}
+
+	for i, test := range tests {
+		// Samples one second apart, so a 2 second window holds 3 samples.
+		e, ok := lh.add(SignalQualitySample{
+			Level: test.level,
+			Time:  start.Add(time.Duration(i) * time.Second),
+		})
+		if ok != test.wantOk {
+			t.Fatalf("step %d: got event %v, want %v", i, ok, test.wantOk)
+		}
+
+		if ok && e.Type != test.want {
+			t.Errorf("step %d: got event type %s, want %s", i, e.Type,
+				test.want)
+		}
+	}
+}
+
+func TestLinkHealthHistory(t *testing.T) {
+	lh := newLinkHealth(4, DefaultLinkHealthThresholds)
+
+	start := time.Now()
+
+	for i := 0; i < 6; i++ {
+		lh.add(SignalQualitySample{
+			Level: uint8(i),
+			Time:  start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	samples := lh.since(time.Time{})
+	if len(samples) != 4 {
+		t.Fatalf("got %d samples, want 4", len(samples))
+	}
+
+	for i, s := range samples {
+		if s.Level != uint8(i+2) {
+			t.Errorf("sample %d: got level %d, want %d", i, s.Level, i+2)
+		}
+	}
+
+	samples = lh.since(start.Add(4 * time.Second))
+	if len(samples) != 2 || samples[0].Level != 4 {
+		t.Errorf("got samples %v, want levels 4 and 5", samples)
+	}
+
+	// Out of order samples are ignored.
+	lh.add(SignalQualitySample{Level: 100, Time: start})
+	if got := lh.h.At(lh.h.Len() - 1).Level; got != 5 {
+		t.Errorf("got last level %d, want 5", got)
+	}
+}
@@ -5,4 +5,22 @@ type Type uint8
 const (
 	TypeWiFiDirect Type = iota
 	TypeRouter
+	TypeStaticIP // Explicit robot IP (no broadcast discovery).
+	TypeUSB      // EP USB (RNDIS) link.
 )
+
+// String returns the connection type as a string.
+func (t Type) String() string {
+	switch t {
+	case TypeWiFiDirect:
+		return "WiFiDirect"
+	case TypeRouter:
+		return "Router"
+	case TypeStaticIP:
+		return "StaticIP"
+	case TypeUSB:
+		return "USB"
+	default:
+		return "Invalid"
+	}
+}
@@ -0,0 +1,133 @@
+package gimbal
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/history"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+// attitudeHistorySize is the number of attitude samples kept in the attitude
+// history. Attitude updates are sent by the robot around 10 times per second
+// so this should cover at least the last minute or so.
+const attitudeHistorySize = 1024
+
+// AttitudeSample is a gimbal attitude (angles in degrees and speeds in
+// degrees/s) and the time it was received.
+type AttitudeSample struct {
+	value.GimbalAttitude
+
+	Time time.Time
+}
+
+// AttitudeCallback is the type of the callback function used to receive
+// gimbal attitude updates.
+type AttitudeCallback func(sample AttitudeSample)
+
+// attitudeHistory is a bounded history of attitude samples ordered by time. It
+// is thread safe.
+type attitudeHistory struct {
+	m sync.Mutex
+	h *history.History[AttitudeSample]
+}
+
+func newAttitudeHistory(size int) *attitudeHistory {
+	return &attitudeHistory{
+		h: history.New(size, func(s AttitudeSample) time.Time {
+			return s.Time
+		}),
+	}
+}
+
+// add adds the given sample to the history, evicting the oldest one if the
+// history is full. Samples older than the newest one are dropped.
+func (h *attitudeHistory) add(s AttitudeSample) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.h.Add(s)
+}
+
+// at returns the attitude at the given time, linearly interpolating between
+// the 2 closest samples. It returns an error if the time is outside of the
+// time range covered by the history.
+func (h *attitudeHistory) at(t time.Time) (AttitudeSample, error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	n := h.h.Len()
+	if n == 0 {
+		return AttitudeSample{}, fmt.Errorf("no attitude samples available")
+	}
+
+	oldest := h.h.At(0)
+	newest := h.h.At(n - 1)
+	if t.Before(oldest.Time) || t.After(newest.Time) {
+		return AttitudeSample{}, fmt.Errorf("time %s outside of attitude "+
+			"history range [%s, %s]", t, oldest.Time, newest.Time)
+	}
+
+	// Index of the first sample not before t.
+	i := h.h.Search(t)
+
+	after := h.h.At(i)
+	if i == 0 || after.Time.Equal(t) {
+		return after, nil
+	}
+
+	before := h.h.At(i - 1)
+
+	f := float32(t.Sub(before.Time)) / float32(after.Time.Sub(before.Time))
+
+	return AttitudeSample{
+		GimbalAttitude: value.GimbalAttitude{
+			Pitch:       lerp(before.Pitch, after.Pitch, f),
+			Yaw:         lerpAngle(before.Yaw, after.Yaw, f),
+			Roll:        lerp(before.Roll, after.Roll, f),
+			YawOpposite: lerpAngle(before.YawOpposite, after.YawOpposite, f),
+			PitchSpeed:  lerp(before.PitchSpeed, after.PitchSpeed, f),
+			YawSpeed:    lerp(before.YawSpeed, after.YawSpeed, f),
+			RollSpeed:   lerp(before.RollSpeed, after.RollSpeed, f),
+		},
+		Time: t,
+	}, nil
+}
+
+// samplesSince returns all samples at or after the given time, oldest first.
+func (h *attitudeHistory) samplesSince(t time.Time) []AttitudeSample {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	return h.h.Since(t)
+}
+
+func lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+// lerpAngle is like lerp but for angles in degrees. If the angles are more than
+// 180 degrees apart, it assumes they wrapped around (from 180 to -180 or vice
+// versa) and interpolates along the shortest angular distance, returning an
+// angle in [-180, 180).
+func lerpAngle(a, b, t float32) float32 {
+	d := b - a
+	if d <= 180 && d >= -180 {
+		return a + d*t
+	}
+
+	return normalizeAngle(a + normalizeAngle(d)*t)
+}
+
+// normalizeAngle returns the given angle (in degrees) normalized to
+// [-180, 180).
+func normalizeAngle(a float32) float32 {
+	a = float32(math.Mod(float64(a)+180, 360))
+	if a < 0 {
+		a += 360
+	}
+
+	return a - 180
+}
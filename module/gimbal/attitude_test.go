@@ -0,0 +1,94 @@
+package gimbal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+func TestAttitudeHistory(t *testing.T) {
+	h := newAttitudeHistory(4)
+
+	if _, err := h.at(time.Now()); err == nil {
+		t.Fatalf("expected error for empty history")
+	}
+
+	start := time.Unix(1000, 0)
+
+	for i := 0; i < 6; i++ {
+		h.add(AttitudeSample{
+			GimbalAttitude: value.GimbalAttitude{
+				Pitch: float32(i),
+				Yaw:   float32(i * 10),
+			},
+			Time: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	// Samples 0 and 1 were evicted.
+	if _, err := h.at(start.Add(1 * time.Second)); err == nil {
+		t.Errorf("expected error for evicted sample")
+	}
+
+	s, err := h.at(start.Add(3 * time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Pitch != 3 || s.Yaw != 30 {
+		t.Errorf("unexpected exact sample: %+v", s)
+	}
+
+	s, err = h.at(start.Add(4500 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Pitch != 4.5 || s.Yaw != 45 {
+		t.Errorf("unexpected interpolated sample: %+v", s)
+	}
+
+	if _, err := h.at(start.Add(6 * time.Second)); err == nil {
+		t.Errorf("expected error for future sample")
+	}
+
+	samples := h.samplesSince(start.Add(3 * time.Second))
+	if len(samples) != 3 || samples[0].Pitch != 3 || samples[2].Pitch != 5 {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+}
+
+func TestAttitudeHistoryYawWraparound(t *testing.T) {
+	h := newAttitudeHistory(4)
+
+	start := time.Unix(1000, 0)
+
+	for i, yaw := range []float32{170, -170, 150} {
+		h.add(AttitudeSample{
+			GimbalAttitude: value.GimbalAttitude{Yaw: yaw},
+			Time:           start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	tests := []struct {
+		offset time.Duration
+		want   float32
+	}{
+		{250 * time.Millisecond, 175},
+		{500 * time.Millisecond, -180},
+		{750 * time.Millisecond, -175},
+		{1500 * time.Millisecond, 170},
+	}
+
+	for _, test := range tests {
+		s, err := h.at(start.Add(test.offset))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if math.Abs(float64(s.Yaw-test.want)) > 1e-3 {
+			t.Errorf("at %s: got yaw %f, want %f", test.offset, s.Yaw,
+				test.want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package gimbal
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/brunoga/robomaster/module"
@@ -13,6 +15,7 @@ import (
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
 )
 
 // Gimbal is the module that allows controlling the gimbal.
@@ -22,6 +25,16 @@ type Gimbal struct {
 	gaToken token.Token
 
 	controlMode ControlMode
+
+	// am serializes angle rotations. See performAngleRotations().
+	am sync.Mutex
+
+	attitude        atomic.Pointer[AttitudeSample]
+	attitudeHistory *attitudeHistory
+
+	tg                *token.Generator
+	m                 sync.RWMutex
+	attitudeCallbacks map[token.Token]AttitudeCallback
 }
 
 var _ module.Module = (*Gimbal)(nil)
@@ -29,7 +42,11 @@ var _ module.Module = (*Gimbal)(nil)
 // New creates a new Gimbal instance.
 func New(ub unitybridge.UnityBridge, l *logger.Logger,
 	cm *connection.Connection) (*Gimbal, error) {
-	g := &Gimbal{}
+	g := &Gimbal{
+		attitudeHistory:   newAttitudeHistory(attitudeHistorySize),
+		tg:                token.NewGenerator(),
+		attitudeCallbacks: make(map[token.Token]AttitudeCallback),
+	}
 
 	g.BaseModule = internal.NewBaseModule(ub, l, "Gimbal",
 		key.KeyGimbalConnection, func(r *result.Result) {
@@ -93,66 +110,79 @@ func (g *Gimbal) SetRotationSpeed(pitch, yaw int16) error {
 		&value.GimbalSpeedRotation{Pitch: pitch * 10, Yaw: yaw * 10, Roll: 0}, nil)
 }
 
-// SetRelativeAngleRotation sets the gimbal rotation relative to the current
-// position. This is executed asynchronously.
-//
-// TODO(bga): Figure out units.
+// SetRelativeAngleRotation rotates the given gimbal axis by the given angle
+// (in degrees) relative to its current position. The rotation will take the
+// given duration (max 10s) to complete. This is executed asynchronously. See
+// SetRelativeAngles() for limits.
 func (g *Gimbal) SetRelativeAngleRotation(angle int16, axis Axis,
 	duration time.Duration) error {
-	if duration > 10*time.Second {
-		return fmt.Errorf("invalid duration %s, max is 10s", duration/time.Second)
-	}
-
-	gimbalIncrementRotation := value.GimbalAngleRotation{
-		Time: int16(duration / time.Millisecond),
-	}
-
-	if axis == AxisPitch {
-		if angle < -60 || angle > 60 {
-			return fmt.Errorf("invalid pitch angle %d, should be between -60 "+
-				"and 60 degrees", angle)
-		}
-
-		gimbalIncrementRotation.Pitch = angle * 10
-		gimbalIncrementRotation.Yaw = 0
-	} else {
-		// TODO(bga): Fix this. It might be just something that needs to be set
-		//            before this is called, like the the chassis or gimbal
-		//            modes.
-		return fmt.Errorf("yaw axis not supported yet")
-		//gimbalIncrementRotation.Pitch = 0
-		//gimbalIncrementRotation.Yaw = angle * 10
+	switch axis {
+	case AxisPitch:
+		return g.SetRelativeAngles(angle, 0, duration)
+	case AxisYaw:
+		return g.SetRelativeAngles(0, angle, duration)
 	}
 
-	return g.UB().PerformActionForKeySync(key.KeyGimbalAngleIncrementRotation,
-		&gimbalIncrementRotation)
+	return fmt.Errorf("invalid axis %d", axis)
 }
 
-// SetAbsoluteAngleRotation sets the absolute gimbal rotation relative to its
-// default position. This is executed asynchronously.
+// SetAbsoluteAngleRotation rotates the given gimbal axis to the given angle
+// (in degrees) relative to its default position. The rotation will take the
+// given duration (max 10s) to complete. This is executed asynchronously. See
+// SetAbsoluteAngles() for limits.
 func (g *Gimbal) SetAbsoluteAngleRotation(angle int16, axis Axis,
 	duration time.Duration) error {
-	gimbalAngleRotation := value.GimbalAngleRotation{
-		Time: int16(duration / time.Millisecond),
+	switch axis {
+	case AxisPitch:
+		return g.setAbsoluteAngles(&angle, nil, duration, false)
+	case AxisYaw:
+		return g.setAbsoluteAngles(nil, &angle, duration, false)
 	}
 
-	var k *key.Key
+	return fmt.Errorf("invalid axis %d", axis)
+}
 
-	if axis == AxisPitch {
-		if angle < -25 || angle > 35 {
-			return fmt.Errorf("invalid pitch angle %d", angle)
-		}
+// SetRelativeAngles rotates the gimbal pitch and yaw axis by the given angles
+// (in degrees) relative to the current position, in a single movement that
+// takes the given duration (max 10s). Pitch must be in [-60, 60]. Relative yaw
+// rotations are not supported yet, so yaw must be 0. This is executed
+// asynchronously.
+//
+// Angle rotations are serialized, so this blocks while a previous rotation
+// started with one of the Sync variants is still in progress.
+func (g *Gimbal) SetRelativeAngles(pitch, yaw int16,
+	duration time.Duration) error {
+	return g.setRelativeAngles(pitch, yaw, duration, false)
+}
 
-		gimbalAngleRotation.Pitch = angle * 10
-		gimbalAngleRotation.Yaw = 0
-		k = key.KeyGimbalAngleFrontPitchRotation
-	} else {
-		gimbalAngleRotation.Pitch = 0
-		gimbalAngleRotation.Yaw = angle * 10
-		k = key.KeyGimbalAngleFrontYawRotation
-	}
+// SetRelativeAnglesSync is like SetRelativeAngles but it blocks until the
+// robot reports the gimbal reached the requested position (or the move
+// failed).
+func (g *Gimbal) SetRelativeAnglesSync(pitch, yaw int16,
+	duration time.Duration) error {
+	return g.setRelativeAngles(pitch, yaw, duration, true)
+}
+
+// SetAbsoluteAngles rotates the gimbal pitch and yaw axis to the given angles
+// (in degrees) relative to the gimbal default position (i.e. pointing forward
+// and aligned with the chassis). Pitch must be in [-25, 35] and yaw in
+// [-180, 180]. This is executed asynchronously.
+//
+// Absolute pitch and yaw rotations are separate robot actions and it was not
+// verified whether starting one cancels the other one in progress, so pitch is
+// rotated first and yaw only after the robot reports the pitch rotation
+// completed. Each rotation takes the given duration (max 10s).
+func (g *Gimbal) SetAbsoluteAngles(pitch, yaw int16,
+	duration time.Duration) error {
+	return g.setAbsoluteAngles(&pitch, &yaw, duration, false)
+}
 
-	return g.UB().PerformActionForKeySync(k, &gimbalAngleRotation)
+// SetAbsoluteAnglesSync is like SetAbsoluteAngles but it blocks until the
+// robot reports the gimbal reached the requested position (or the move
+// failed).
+func (g *Gimbal) SetAbsoluteAnglesSync(pitch, yaw int16,
+	duration time.Duration) error {
+	return g.setAbsoluteAngles(&pitch, &yaw, duration, true)
 }
 
 // StopRotation stops any ongoing gimbal rotation.
@@ -208,6 +238,68 @@ func (g *Gimbal) ResetPosition() error {
 	return nil
 }
 
+// Attitude returns the latest gimbal attitude received from the robot. It
+// returns false if no attitude was received yet.
+func (g *Gimbal) Attitude() (AttitudeSample, bool) {
+	a := g.attitude.Load()
+	if a == nil {
+		return AttitudeSample{}, false
+	}
+
+	return *a, true
+}
+
+// AttitudeAt returns the gimbal attitude at the given time, interpolated from
+// the samples received around it. Only a limited number of past samples are
+// kept, so this returns an error if the time is too far in the past (or in the
+// future). This is useful, for example, to know where the gimbal was pointing
+// to when a specific video frame was received.
+func (g *Gimbal) AttitudeAt(t time.Time) (AttitudeSample, error) {
+	return g.attitudeHistory.at(t)
+}
+
+// AttitudeHistory returns all the available attitude samples received at or
+// after the given time, oldest first.
+func (g *Gimbal) AttitudeHistory(since time.Time) []AttitudeSample {
+	return g.attitudeHistory.samplesSince(since)
+}
+
+// AddAttitudeCallback adds a callback function to be called whenever a new
+// gimbal attitude is received from the robot. Callbacks are called as samples
+// arrive (so in order) and must not block. Returns a token that can be used to
+// remove the callback later.
+func (g *Gimbal) AddAttitudeCallback(ac AttitudeCallback) (token.Token,
+	error) {
+	if ac == nil {
+		return 0, fmt.Errorf("callback must not be nil")
+	}
+
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	t := g.tg.Next()
+
+	g.attitudeCallbacks[t] = ac
+
+	return t, nil
+}
+
+// RemoveAttitudeCallback removes the callback function associated with the
+// given token.
+func (g *Gimbal) RemoveAttitudeCallback(t token.Token) error {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	_, ok := g.attitudeCallbacks[t]
+	if !ok {
+		return fmt.Errorf("no callback added for token %d", t)
+	}
+
+	delete(g.attitudeCallbacks, t)
+
+	return nil
+}
+
 func (g *Gimbal) ControlMode() ControlMode {
 	return g.controlMode
 }
@@ -269,19 +361,205 @@ func (g *Gimbal) Stop() error {
 	return g.BaseModule.Stop()
 }
 
+func (g *Gimbal) setRelativeAngles(pitch, yaw int16, duration time.Duration,
+	wait bool) error {
+	err := checkDuration(duration)
+	if err != nil {
+		return err
+	}
+
+	if pitch < minRelativePitch || pitch > maxRelativePitch {
+		return fmt.Errorf("invalid pitch angle %d, should be between %d "+
+			"and %d degrees", pitch, minRelativePitch, maxRelativePitch)
+	}
+
+	if yaw != 0 {
+		// TODO(bga): Fix this. It might be just something that needs to be set
+		//            before this is called, like the chassis or gimbal
+		//            modes.
+		return fmt.Errorf("yaw axis not supported yet")
+	}
+
+	// Angles are sent in tenths of degree and time in milliseconds.
+	return g.performAngleRotations([]*key.Key{
+		key.KeyGimbalAngleIncrementRotation,
+	}, []*value.GimbalAngleRotation{
+		{
+			Pitch: pitch * 10,
+			Time:  int16(duration / time.Millisecond),
+		},
+	}, duration, wait)
+}
+
+func (g *Gimbal) setAbsoluteAngles(pitch, yaw *int16, duration time.Duration,
+	wait bool) error {
+	err := checkDuration(duration)
+	if err != nil {
+		return err
+	}
+
+	// Absolute pitch and yaw rotations are independent actions.
+	var ks []*key.Key
+	var vs []*value.GimbalAngleRotation
+
+	if pitch != nil {
+		if *pitch < minAbsolutePitch || *pitch > maxAbsolutePitch {
+			return fmt.Errorf("invalid pitch angle %d, should be between %d "+
+				"and %d degrees", *pitch, minAbsolutePitch, maxAbsolutePitch)
+		}
+
+		ks = append(ks, key.KeyGimbalAngleFrontPitchRotation)
+		vs = append(vs, &value.GimbalAngleRotation{
+			Pitch: *pitch * 10,
+			Time:  int16(duration / time.Millisecond),
+		})
+	}
+
+	if yaw != nil {
+		if *yaw < minYaw || *yaw > maxYaw {
+			return fmt.Errorf("invalid yaw angle %d, should be between %d "+
+				"and %d degrees", *yaw, minYaw, maxYaw)
+		}
+
+		ks = append(ks, key.KeyGimbalAngleFrontYawRotation)
+		vs = append(vs, &value.GimbalAngleRotation{
+			Yaw:  *yaw * 10,
+			Time: int16(duration / time.Millisecond),
+		})
+	}
+
+	return g.performAngleRotations(ks, vs, duration, wait)
+}
+
+// performAngleRotations performs the given angle rotation actions in order.
+// If wait is true, it also waits for the robot to report all of the associated
+// gimbal angle tasks as completed.
+//
+// Task status updates only identify the task type (see value.TaskStatus), not
+// the specific rotation, so there is no way to tell which rotation a status
+// update refers to. To avoid concurrent rotations completing each other,
+// rotations are serialized and, when there is more than one action, each one
+// is only sent after the previous one completed.
+func (g *Gimbal) performAngleRotations(ks []*key.Key,
+	vs []*value.GimbalAngleRotation, duration time.Duration, wait bool) error {
+	if !wait && len(ks) > 1 {
+		// We need to wait for each rotation before sending the next one, so
+		// do it in the background.
+		go func() {
+			err := g.performAngleRotations(ks, vs, duration, true)
+			if err != nil {
+				g.Logger().Error("Error performing gimbal angle rotations",
+					"error", err)
+			}
+		}()
+
+		return nil
+	}
+
+	g.am.Lock()
+	defer g.am.Unlock()
+
+	for i, k := range ks {
+		err := g.performAngleRotation(k, vs[i], duration, wait)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// performAngleRotation performs the given angle rotation action and, if wait is
+// true, waits for the robot to report the associated gimbal angle task as
+// completed. Must be called with am held.
+func (g *Gimbal) performAngleRotation(k *key.Key,
+	v *value.GimbalAngleRotation, duration time.Duration, wait bool) error {
+	if !wait {
+		return g.UB().PerformActionForKeySync(k, v)
+	}
+
+	// Listen for task status updates before starting the rotation so we do
+	// not miss any of them.
+	statusCh := make(chan task.Status, 1)
+
+	t, err := g.UB().AddKeyListener(key.KeyRobomasterSystemTaskStatus,
+		func(r *result.Result) {
+			if r == nil || !r.Succeeded() {
+				return
+			}
+
+			ts, ok := r.Value().(*value.TaskStatus)
+			if !ok || ts.TaskType != task.TypeGimbalAngle ||
+				ts.Status == task.StatusRunning {
+				return
+			}
+
+			select {
+			case statusCh <- ts.Status:
+			default:
+			}
+		}, false)
+	if err != nil {
+		return err
+	}
+	defer g.UB().RemoveKeyListener(key.KeyRobomasterSystemTaskStatus, t)
+
+	err = g.UB().PerformActionForKeySync(k, v)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case status := <-statusCh:
+		if status != task.StatusSuccess {
+			return fmt.Errorf("gimbal angle rotation failed")
+		}
+	case <-time.After(duration + 5*time.Second):
+		return fmt.Errorf("timeout waiting for gimbal angle rotation")
+	}
+
+	return nil
+}
+
+func checkDuration(duration time.Duration) error {
+	if duration < 0 || duration > 10*time.Second {
+		return fmt.Errorf("invalid duration %s, max is 10s", duration)
+	}
+
+	return nil
+}
+
 func (g *Gimbal) onAttitudeUpdates(r *result.Result) {
 	if r == nil || !r.Succeeded() {
 		g.Logger().Error("Error getting gimbal attitude", "error", r.ErrorDesc())
 		return
 	}
 
-	value, ok := r.Value().(*value.GimbalAttitude)
+	ga, ok := r.Value().(*value.GimbalAttitude)
 	if !ok {
-		g.Logger().Error("Unexpected result value", "key", r.Key(), "value", value)
+		g.Logger().Error("Unexpected result value", "key", r.Key(), "value",
+			r.Value())
 		return
 	}
 
-	g.Logger().Info("Gimbal attitude", "pitch", value.Pitch, "roll", value.Roll,
-		"yaw", value.Yaw, "yawOpposite", value.YawOpposite, "pitchSpeed",
-		value.PitchSpeed, "rollSpeed", value.RollSpeed, "yawSpeed", value.YawSpeed)
+	sample := AttitudeSample{
+		GimbalAttitude: *ga,
+		Time:           time.Now(),
+	}
+
+	g.attitude.Store(&sample)
+	g.attitudeHistory.add(sample)
+
+	// Callbacks are called without holding the lock so they can add or
+	// remove callbacks.
+	g.m.RLock()
+	callbacks := make([]AttitudeCallback, 0, len(g.attitudeCallbacks))
+	for _, ac := range g.attitudeCallbacks {
+		callbacks = append(callbacks, ac)
+	}
+	g.m.RUnlock()
+
+	for _, ac := range callbacks {
+		ac(sample)
+	}
 }
@@ -0,0 +1,23 @@
+package gimbal
+
+// Gimbal angle limits (in degrees). All angle checks in this package use
+// these.
+//
+// The pitch limits are the ones the module always used for relative and
+// absolute angle rotations.
+//
+// The official RoboMaster SDK documents [-250, 250] as the yaw range for
+// absolute moves (robomaster.gimbal.Gimbal.moveto). Gimbal attitude yaw is
+// handled wrapped to [-180, 180) though (see normalizeAngle), so positions
+// outside of that range could not be told apart from their wrapped
+// equivalents. Yaw is limited to [-180, 180] for this reason.
+const (
+	minRelativePitch = -60
+	maxRelativePitch = 60
+
+	minAbsolutePitch = -25
+	maxAbsolutePitch = 35
+
+	minYaw = -180
+	maxYaw = 180
+)
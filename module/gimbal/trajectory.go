@@ -0,0 +1,176 @@
+package gimbal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// trajectoryInterval is how often speeds are updated while executing a
+	// trajectory.
+	trajectoryInterval = 50 * time.Millisecond
+
+	// trajectoryGain is the proportional gain used to correct the difference
+	// between the planned and the actual gimbal position (1/s).
+	trajectoryGain = 4.0
+
+	// trajectoryMaxSpeed is the maximum speed used while executing a
+	// trajectory (degrees/s).
+	trajectoryMaxSpeed = 360.0
+)
+
+// Waypoint is a gimbal position (in degrees, relative to the gimbal default
+// position) and the time it should take to reach it from the previous
+// waypoint (or from the current position for the first one).
+type Waypoint struct {
+	Pitch float64
+	Yaw   float64
+	Time  time.Duration
+}
+
+// Limits are soft angle limits (in degrees, relative to the gimbal default
+// position) for trajectories. Waypoints outside of the limits are rejected and
+// the gimbal is never driven further outside of them.
+type Limits struct {
+	MinPitch float64
+	MaxPitch float64
+	MinYaw   float64
+	MaxYaw   float64
+}
+
+// DefaultLimits are the limits used when none are given. They are the same
+// limits enforced for absolute angle rotations (see SetAbsoluteAngles()).
+var DefaultLimits = Limits{
+	MinPitch: minAbsolutePitch,
+	MaxPitch: maxAbsolutePitch,
+	MinYaw:   minYaw,
+	MaxYaw:   maxYaw,
+}
+
+// RunTrajectory smoothly moves the gimbal through the given waypoints using
+// minimum-jerk interpolation between them. Speeds are continuously corrected
+// using gimbal attitude updates so the gimbal follows the planned path even
+// if individual speed commands are not exact. This blocks until the last
+// waypoint is reached or the given context is done (in which case the gimbal
+// is stopped where it is and the context error is returned). If limits is
+// nil, DefaultLimits are used.
+func (g *Gimbal) RunTrajectory(ctx context.Context, waypoints []Waypoint,
+	limits *Limits) error {
+	if limits == nil {
+		limits = &DefaultLimits
+	}
+
+	if len(waypoints) == 0 {
+		return fmt.Errorf("no waypoints")
+	}
+
+	for i, wp := range waypoints {
+		if wp.Time <= 0 {
+			return fmt.Errorf("waypoint %d: invalid time %s", i, wp.Time)
+		}
+
+		if wp.Pitch < limits.MinPitch || wp.Pitch > limits.MaxPitch ||
+			wp.Yaw < limits.MinYaw || wp.Yaw > limits.MaxYaw {
+			return fmt.Errorf("waypoint %d: position (%f, %f) outside of "+
+				"limits %+v", i, wp.Pitch, wp.Yaw, *limits)
+		}
+	}
+
+	current, ok := g.Attitude()
+	if !ok {
+		return fmt.Errorf("no gimbal attitude available")
+	}
+
+	defer g.StopRotation()
+
+	ticker := time.NewTicker(trajectoryInterval)
+	defer ticker.Stop()
+
+	fromPitch := float64(current.Pitch)
+	fromYaw := float64(current.Yaw)
+
+	for _, wp := range waypoints {
+		start := time.Now()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+
+			elapsed := time.Since(start)
+
+			pitch, pitchSpeed := minimumJerk(fromPitch, wp.Pitch, wp.Time,
+				elapsed)
+			yaw, yawSpeed := minimumJerk(fromYaw, wp.Yaw, wp.Time, elapsed)
+
+			if a, ok := g.Attitude(); ok {
+				pitchSpeed = trajectorySpeed(pitchSpeed, pitch,
+					float64(a.Pitch), limits.MinPitch, limits.MaxPitch)
+				yawSpeed = trajectorySpeed(yawSpeed, yaw,
+					unwrapAngle(float64(a.Yaw), yaw), limits.MinYaw,
+					limits.MaxYaw)
+			}
+
+			err := g.SetRotationSpeed(int16(math.Round(pitchSpeed)),
+				int16(math.Round(yawSpeed)))
+			if err != nil {
+				return err
+			}
+
+			if elapsed >= wp.Time {
+				break
+			}
+		}
+
+		fromPitch = wp.Pitch
+		fromYaw = wp.Yaw
+	}
+
+	return nil
+}
+
+// minimumJerk returns the position and speed (per second) at the given elapsed
+// time for a minimum-jerk movement from the given position to the given
+// position taking the given total time.
+func minimumJerk(from, to float64, total, elapsed time.Duration) (float64,
+	float64) {
+	if elapsed >= total {
+		return to, 0
+	}
+
+	t := elapsed.Seconds() / total.Seconds()
+	t2 := t * t
+	t3 := t2 * t
+
+	s := 10*t3 - 15*t3*t + 6*t3*t2
+	ds := 30*t2 - 60*t3 + 30*t3*t
+
+	return from + (to-from)*s, (to - from) * ds / total.Seconds()
+}
+
+// trajectorySpeed returns the speed to use given the planned speed and
+// position and the actual position. Speed is corrected proportionally to the
+// position error and never drives the gimbal further outside of the limits.
+func trajectorySpeed(plannedSpeed, plannedPosition, actualPosition, min,
+	max float64) float64 {
+	speed := plannedSpeed + trajectoryGain*(plannedPosition-actualPosition)
+
+	if (actualPosition >= max && speed > 0) ||
+		(actualPosition <= min && speed < 0) {
+		return 0
+	}
+
+	return math.Max(-trajectoryMaxSpeed, math.Min(trajectoryMaxSpeed, speed))
+}
+
+// unwrapAngle returns the given angle (which is wrapped to [-180, 180)) plus
+// or minus whole turns so it is as close as possible to the given reference
+// angle. This allows comparing reported yaw angles with planned ones that
+// reach (or, transiently, go past) +/-180 degrees.
+func unwrapAngle(a, ref float64) float64 {
+	return ref + float64(normalizeAngle(float32(a-ref)))
+}
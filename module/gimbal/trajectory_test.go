@@ -0,0 +1,68 @@
+package gimbal
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMinimumJerk(t *testing.T) {
+	total := 2 * time.Second
+
+	p, v := minimumJerk(10, 30, total, 0)
+	if p != 10 || v != 0 {
+		t.Errorf("unexpected start: position=%f, speed=%f", p, v)
+	}
+
+	// Minimum-jerk movements are symmetric, so half way through the position
+	// is the middle point and the speed is at its peak (1.875 times the
+	// average speed).
+	p, v = minimumJerk(10, 30, total, 1*time.Second)
+	if math.Abs(p-20) > 1e-9 || math.Abs(v-18.75) > 1e-9 {
+		t.Errorf("unexpected middle: position=%f, speed=%f", p, v)
+	}
+
+	p, v = minimumJerk(10, 30, total, 3*time.Second)
+	if p != 30 || v != 0 {
+		t.Errorf("unexpected end: position=%f, speed=%f", p, v)
+	}
+}
+
+func TestTrajectorySpeed(t *testing.T) {
+	// On track.
+	if s := trajectorySpeed(10, 5, 5, -25, 35); s != 10 {
+		t.Errorf("expected 10, got %f", s)
+	}
+
+	// Lagging behind.
+	if s := trajectorySpeed(10, 5, 4, -25, 35); s != 10+trajectoryGain {
+		t.Errorf("expected %f, got %f", 10+trajectoryGain, s)
+	}
+
+	// At the limit.
+	if s := trajectorySpeed(10, 40, 35, -25, 35); s != 0 {
+		t.Errorf("expected 0, got %f", s)
+	}
+
+	// Clamped.
+	if s := trajectorySpeed(1000, 0, 0, -25, 35); s != trajectoryMaxSpeed {
+		t.Errorf("expected %f, got %f", trajectoryMaxSpeed, s)
+	}
+}
+
+func TestUnwrapAngle(t *testing.T) {
+	for _, test := range []struct {
+		a, ref, want float64
+	}{
+		{10, 20, 10},
+		{-179, 178, 181},
+		{179, -178, -181},
+		{-90, 90, -90},
+	} {
+		got := unwrapAngle(test.a, test.ref)
+		if math.Abs(got-test.want) > 1e-3 {
+			t.Errorf("unwrapAngle(%f, %f): got %f, want %f", test.a, test.ref,
+				got, test.want)
+		}
+	}
+}
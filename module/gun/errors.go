@@ -0,0 +1,18 @@
+package gun
+
+import "errors"
+
+var (
+	// ErrNotConnected is returned when trying to use the gun while no gun
+	// (water or infrared) is connected.
+	ErrNotConnected = errors.New("no gun connected")
+
+	// ErrTypeNotAvailable is returned when trying to fire with a type that is
+	// not supported by the currently connected gun(s).
+	ErrTypeNotAvailable = errors.New("gun type not available")
+
+	// ErrOverheated is returned when trying to fire while the barrel is
+	// overheated and the overheat policy is OverheatPolicyReject (or when
+	// waiting for it to cool down timed out).
+	ErrOverheated = errors.New("gun barrel overheated")
+)
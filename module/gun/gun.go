@@ -3,23 +3,69 @@ package gun
 import (
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/connection"
 	"github.com/brunoga/robomaster/module/robot"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/listener"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+const (
+	// defaultFireRate is the fire rate (shots per second) used until a
+	// different one is set.
+	defaultFireRate = 5
+
+	// maxFireRate is the maximum supported fire rate (shots per second).
+	maxFireRate = 10
+
+	// overheatQueueTimeout is the maximum time to wait for the barrel to cool
+	// down when using OverheatPolicyQueue.
+	overheatQueueTimeout = 10 * time.Second
 )
 
 // Gun is the module that controls turret firing. It supports both infrared and
-// beads firing.
+// beads firing. The water (bead) gun and the infrared gun are tracked
+// independently and the module is considered connected if any of them is.
 type Gun struct {
 	ub unitybridge.UnityBridge
 	l  *logger.Logger
 	rm *robot.Robot
 	cm *connection.Connection
+
+	cdToken token.Token
+
+	infraredConnectionRL *listener.Listener
+
+	beadFireRate     atomic.Uint64
+	infraredFireRate atomic.Uint64
+	overheatPolicy   atomic.Uint32
+
+	om         sync.Mutex
+	overheated bool
+	cooled     chan struct{}
+
+	m           sync.Mutex
+	continuousQ chan struct{}
+	continuousD chan struct{}
+
+	// im guards infrared firing so overlapping bursts extend the pending
+	// "off" instead of cutting each other short.
+	im         sync.Mutex
+	irOffTimer *time.Timer
+	irOffAt    time.Time
+
+	// irFireKey is the key infrared firing was last enabled with, so it is
+	// disabled with the same one.
+	irFireKey atomic.Pointer[key.Key]
 }
 
 var _ module.Module = (*Gun)(nil)
@@ -33,22 +79,63 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 
 	l = l.WithGroup("gun_module")
 
-	return &Gun{
+	g := &Gun{
 		ub: ub,
 		l:  l,
 		rm: rm,
 		cm: cm,
-	}, nil
+	}
+
+	g.infraredConnectionRL = listener.New(ub, l,
+		key.KeyRobomasterInfraredGunConnection, func(r *result.Result) {
+			connected, ok := r.Value().(*value.Bool)
+			if !ok {
+				l.Error("Unexpected infrared gun connection value.", "value",
+					r.Value())
+				return
+			}
+
+			l.Debug("Infrared gun connection changed.", "connected",
+				connected.Value)
+		})
+
+	g.beadFireRate.Store(defaultFireRate)
+	g.infraredFireRate.Store(defaultFireRate)
+
+	return g, nil
 }
 
 // Start starts the Gun module.
 func (g *Gun) Start() error {
-	return g.rm.EnableFunction(robot.FunctionTypeGunControl, true)
+	var err error
+
+	err = g.infraredConnectionRL.Start()
+	if err != nil {
+		return err
+	}
+
+	g.cdToken, err = g.ub.AddKeyListener(key.KeyRobomasterSystemGunCoolDown,
+		g.onGunCoolDown, true)
+	if err != nil {
+		g.infraredConnectionRL.Stop()
+		return err
+	}
+
+	err = g.rm.EnableFunction(robot.FunctionTypeGunControl, true)
+	if err != nil {
+		g.ub.RemoveKeyListener(key.KeyRobomasterSystemGunCoolDown, g.cdToken)
+		g.infraredConnectionRL.Stop()
+		return err
+	}
+
+	return nil
 }
 
-// Connected returns whether the Gun module is connected.
+// Connected returns whether the Gun module is connected (i.e. if any of the
+// water or infrared guns is connected).
 func (g *Gun) Connected() bool {
-	return g.rm.HasDevice(robot.DeviceTypeWaterGun) && g.cm.Connected()
+	return g.cm.Connected() && (g.waterGunConnected() ||
+		g.infraredGunConnected())
 }
 
 // WaitForConnection waits for the Gun module to connect and returns the
@@ -66,25 +153,273 @@ func (g *Gun) WaitForConnection(timeout time.Duration) bool {
 		return false
 	}
 
-	return g.rm.HasDevice(robot.DeviceTypeWaterGun)
+	return g.waterGunConnected() || g.infraredGunConnected()
 }
 
-// Fire fires the Gun module with the given type.
+// HasType returns true if firing with the given type is currently possible
+// (i.e. the associated gun is connected).
+func (g *Gun) HasType(typ Type) bool {
+	switch typ {
+	case TypeBead:
+		return g.waterGunConnected()
+	case TypeInfrared:
+		// The water gun also supports infrared firing.
+		return g.waterGunConnected() || g.infraredGunConnected()
+	}
+
+	return false
+}
+
+// Types returns the list of types that can currently be used for firing.
+func (g *Gun) Types() []Type {
+	var types []Type
+	for typ := TypeBead; typ.Valid(); typ++ {
+		if g.HasType(typ) {
+			types = append(types, typ)
+		}
+	}
+
+	return types
+}
+
+// FirmwareVersion returns the firmware version of the gun associated with
+// the given type.
+func (g *Gun) FirmwareVersion(typ Type) (string, error) {
+	var k *key.Key
+	switch typ {
+	case TypeBead:
+		if !g.waterGunConnected() {
+			return "", ErrNotConnected
+		}
+		k = key.KeyRobomasterWaterGunFirmwareVersion
+	case TypeInfrared:
+		if !g.infraredGunConnected() {
+			return "", ErrNotConnected
+		}
+		k = key.KeyRobomasterInfraredGunFirmwareVersion
+	default:
+		return "", fmt.Errorf("invalid gun type: %v", typ)
+	}
+
+	r, err := g.ub.GetKeyValueSync(k, true)
+	if err != nil {
+		return "", err
+	}
+
+	v, ok := r.Value().(*value.String)
+	if !ok {
+		return "", fmt.Errorf("unexpected value: %v", r.Value())
+	}
+
+	return v.Value, nil
+}
+
+// Fire fires the Gun module with the given type once.
 func (g *Gun) Fire(typ Type) error {
+	return g.FireBurst(typ, 1)
+}
+
+// FireBurst fires the given number of shots with the given type at the
+// current fire rate for that type (see SetFireRate()). This returns as soon
+// as the burst starts. If the barrel is overheated and the overheat policy is
+// OverheatPolicyQueue, this first blocks until the barrel cools down (for up
+// to 10 seconds, after which ErrOverheated is returned).
+func (g *Gun) FireBurst(typ Type, count uint8) error {
+	if !typ.Valid() {
+		return fmt.Errorf("invalid gun type: %v", typ)
+	}
+
+	if count == 0 {
+		return fmt.Errorf("invalid shot count: %d", count)
+	}
+
+	err := g.checkType(typ)
+	if err != nil {
+		return err
+	}
+
+	err = g.checkOverheated()
+	if err != nil {
+		return err
+	}
+
 	switch typ {
 	case TypeBead:
-		// TODO(bga): Maybe implement firing multiple beads and extend it to IR
-		//            firing too.
-		return g.fireBead(1)
+		return g.fireBead(uint64(count))
 	case TypeInfrared:
-		return g.fireInfrared()
+		return g.fireInfrared(time.Duration(count) * g.shotInterval(typ))
+	}
+
+	return nil
+}
+
+// StartContinuousFire starts firing with the given type at the current fire
+// rate for that type (see SetFireRate()) until StopContinuousFire() is called.
+// While the barrel is overheated, firing either stops (OverheatPolicyReject)
+// or pauses until the barrel cools down (OverheatPolicyQueue).
+func (g *Gun) StartContinuousFire(typ Type) error {
+	if !typ.Valid() {
+		return fmt.Errorf("invalid gun type: %v", typ)
+	}
+
+	err := g.checkType(typ)
+	if err != nil {
+		return err
+	}
+
+	err = g.checkOverheated()
+	if err != nil {
+		return err
+	}
+
+	return g.startContinuousFire(typ)
+}
+
+// StopContinuousFire stops continuous firing started with
+// StartContinuousFire().
+func (g *Gun) StopContinuousFire() error {
+	g.m.Lock()
+	quit, done := g.continuousQ, g.continuousD
+	g.continuousQ = nil
+	g.continuousD = nil
+	g.m.Unlock()
+
+	if quit == nil {
+		return fmt.Errorf("continuous fire not started")
+	}
+
+	// The loop might need m when exiting, so it must not be held here.
+	close(quit)
+	<-done
+
+	return nil
+}
+
+// FireRate returns the current fire rate (shots per second) for the given
+// type.
+func (g *Gun) FireRate(typ Type) uint64 {
+	if typ == TypeInfrared {
+		return g.infraredFireRate.Load()
+	}
+
+	return g.beadFireRate.Load()
+}
+
+// SetFireRate sets the fire rate (shots per second, 1 to 10) for the given
+// type.
+func (g *Gun) SetFireRate(typ Type, rate uint64) error {
+	if !typ.Valid() {
+		return fmt.Errorf("invalid gun type: %v", typ)
+	}
+
+	if rate == 0 || rate > maxFireRate {
+		return fmt.Errorf("invalid fire rate %d, should be between 1 and %d",
+			rate, maxFireRate)
+	}
+
+	k := key.KeyRobomasterWaterGunShootFrequency
+	if typ == TypeInfrared {
+		k = key.KeyRobomasterInfraredGunShootFrequency
+	}
+
+	err := g.ub.SetKeyValueSync(k, &value.Uint64{Value: rate})
+	if err != nil {
+		return err
+	}
+
+	if typ == TypeInfrared {
+		g.infraredFireRate.Store(rate)
+	} else {
+		g.beadFireRate.Store(rate)
+	}
+
+	return nil
+}
+
+// ShootSpeed returns the current bead shoot speed.
+func (g *Gun) ShootSpeed() (uint64, error) {
+	r, err := g.ub.GetKeyValueSync(key.KeyRobomasterWaterGunShootSpeed, true)
+	if err != nil {
+		return 0, err
+	}
+
+	v, ok := r.Value().(*value.Uint64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected value: %v", r.Value())
+	}
+
+	return v.Value, nil
+}
+
+// SetShootSpeed sets the bead shoot speed.
+func (g *Gun) SetShootSpeed(speed uint64) error {
+	return g.ub.SetKeyValueSync(key.KeyRobomasterWaterGunShootSpeed,
+		&value.Uint64{Value: speed})
+}
+
+// IsOverheated returns true if the robot reported the barrel as overheated
+// and it did not cool down yet.
+func (g *Gun) IsOverheated() bool {
+	g.om.Lock()
+	defer g.om.Unlock()
+
+	return g.overheated
+}
+
+// OverheatPolicy returns the current overheat policy.
+func (g *Gun) OverheatPolicy() OverheatPolicy {
+	return OverheatPolicy(g.overheatPolicy.Load())
+}
+
+// SetOverheatPolicy sets what happens when trying to fire while the barrel is
+// overheated. The default is OverheatPolicyReject.
+func (g *Gun) SetOverheatPolicy(p OverheatPolicy) error {
+	if !p.Valid() {
+		return fmt.Errorf("invalid overheat policy: %d", p)
 	}
 
-	return fmt.Errorf("invalid gun type: %v", typ)
+	g.overheatPolicy.Store(uint32(p))
+
+	return nil
+}
+
+// CoolDownBarrel asks the robot to cool down the barrel.
+func (g *Gun) CoolDownBarrel() error {
+	return g.ub.PerformActionForKeySync(key.KeyRobomasterSystemBarrelCoolDown,
+		nil)
+}
+
+// ResetBarrelOverheat resets the barrel overheat status so firing can resume
+// immediately.
+func (g *Gun) ResetBarrelOverheat() error {
+	return g.ub.PerformActionForKeySync(
+		key.KeyRobomasterSystemResetBarrelOverheat, nil)
 }
 
 // Stop stops the Gun module.
 func (g *Gun) Stop() error {
+	// Not an error if continuous fire was not started.
+	g.StopContinuousFire()
+
+	g.im.Lock()
+	if g.irOffTimer != nil {
+		g.irOffTimer.Stop()
+		g.irOffTimer = nil
+		g.setInfraredFiring(false)
+	}
+	g.im.Unlock()
+
+	err := g.ub.RemoveKeyListener(key.KeyRobomasterSystemGunCoolDown,
+		g.cdToken)
+	if err != nil {
+		return err
+	}
+
+	err = g.infraredConnectionRL.Stop()
+	if err != nil {
+		return err
+	}
+
 	return g.rm.EnableFunction(robot.FunctionTypeGunControl, false)
 }
 
@@ -93,22 +428,250 @@ func (G *Gun) String() string {
 	return "Gun"
 }
 
-type timesValue struct {
-	Value uint64 `json:"value"`
-}
-
 func (g *Gun) fireBead(times uint64) error {
 	return g.ub.PerformActionForKey(key.KeyRobomasterWaterGunWaterGunFireWithTimes,
-		timesValue{times}, nil)
+		&value.Uint64{Value: times}, nil)
 }
 
-func (g *Gun) fireInfrared() error {
-	go func() {
-		// Disable firing after a while.
-		time.Sleep(200 * time.Millisecond)
+// fireInfrared enables infrared firing for the given duration. If a previous
+// burst is still in progress, it is extended so both bursts complete.
+func (g *Gun) fireInfrared(d time.Duration) error {
+	g.im.Lock()
+	defer g.im.Unlock()
+
+	err := g.setInfraredFiring(true)
+	if err != nil {
+		return err
+	}
+
+	offAt := time.Now().Add(d)
+
+	if g.irOffTimer != nil {
+		if !offAt.After(g.irOffAt) {
+			// The pending burst already covers this one.
+			return nil
+		}
+
+		g.irOffTimer.Stop()
+	}
+
+	g.irOffAt = offAt
+	g.irOffTimer = time.AfterFunc(d, g.onInfraredOff)
+
+	return nil
+}
+
+// onInfraredOff disables infrared firing at the end of a burst.
+func (g *Gun) onInfraredOff() {
+	g.im.Lock()
+	defer g.im.Unlock()
+
+	if g.irOffTimer == nil || time.Now().Before(g.irOffAt) {
+		// Burst was extended (or canceled) after this timer fired.
+		return
+	}
+
+	g.irOffTimer = nil
+
+	err := g.setInfraredFiring(false)
+	if err != nil {
+		g.l.Error("Error disabling infrared firing.", "error", err)
+	}
+}
+
+func (g *Gun) setInfraredFiring(enabled bool) error {
+	if !enabled {
+		k := g.irFireKey.Swap(nil)
+		if k == nil {
+			k = g.infraredFireKey()
+		}
+
+		return g.ub.DirectSendKeyValue(k, 0)
+	}
+
+	k := g.infraredFireKey()
+	g.irFireKey.Store(k)
+
+	return g.ub.DirectSendKeyValue(k, 1)
+}
+
+// infraredFireKey returns the key used for infrared firing. The infrared gun
+// has its own key but the water gun can also fire infrared beams.
+func (g *Gun) infraredFireKey() *key.Key {
+	if g.infraredGunConnected() {
+		return key.KeyRobomasterInfraredGunInfraredGunFire
+	}
+
+	return key.KeyRobomasterWaterGunWaterGunFire
+}
+
+// startContinuousFire starts the continuous fire loop for the given type.
+func (g *Gun) startContinuousFire(typ Type) error {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	if g.continuousQ != nil {
+		return fmt.Errorf("continuous fire already started")
+	}
 
-		g.ub.DirectSendKeyValue(key.KeyRobomasterWaterGunWaterGunFire, uint64(0))
+	g.continuousQ = make(chan struct{})
+	g.continuousD = make(chan struct{})
+
+	go g.continuousFireLoop(typ, g.continuousQ, g.continuousD)
+
+	return nil
+}
+
+func (g *Gun) continuousFireLoop(typ Type, quit chan struct{},
+	done chan<- struct{}) {
+	defer close(done)
+
+	defer func() {
+		// Clear the continuous fire state if the loop exited on its own
+		// (otherwise StopContinuousFire() already did it).
+		g.m.Lock()
+		if g.continuousQ == quit {
+			g.continuousQ = nil
+			g.continuousD = nil
+		}
+		g.m.Unlock()
+	}()
+
+	firing := false
+	defer func() {
+		if firing {
+			g.setInfraredFiring(false)
+		}
 	}()
 
-	return g.ub.DirectSendKeyValue(key.KeyRobomasterWaterGunWaterGunFire, uint64(1))
+	for {
+		g.om.Lock()
+		overheated, cooled := g.overheated, g.cooled
+		g.om.Unlock()
+
+		if overheated {
+			if firing {
+				g.setInfraredFiring(false)
+				firing = false
+			}
+
+			if g.OverheatPolicy() == OverheatPolicyReject {
+				g.l.Warn("Barrel overheated. Continuous fire stopped.")
+				return
+			}
+
+			select {
+			case <-quit:
+				return
+			case <-cooled:
+				continue
+			}
+		}
+
+		var err error
+		switch typ {
+		case TypeBead:
+			err = g.fireBead(1)
+		case TypeInfrared:
+			// Infrared keeps firing at the configured rate while enabled.
+			if !firing {
+				err = g.setInfraredFiring(true)
+				firing = err == nil
+			}
+		}
+		if err != nil {
+			g.l.Error("Continuous fire error.", "error", err)
+		}
+
+		select {
+		case <-quit:
+			return
+		case <-time.After(g.shotInterval(typ)):
+		}
+	}
+}
+
+// checkType returns a non-nil error if firing with the given type is not
+// currently possible.
+func (g *Gun) checkType(typ Type) error {
+	if !g.waterGunConnected() && !g.infraredGunConnected() {
+		return ErrNotConnected
+	}
+
+	if !g.HasType(typ) {
+		return fmt.Errorf("%w: %s", ErrTypeNotAvailable, typ)
+	}
+
+	return nil
+}
+
+func (g *Gun) waterGunConnected() bool {
+	return g.rm.HasDevice(robot.DeviceTypeWaterGun)
+}
+
+func (g *Gun) infraredGunConnected() bool {
+	if g.rm.HasDevice(robot.DeviceTypeInfraredGun) {
+		return true
+	}
+
+	r := g.infraredConnectionRL.Result()
+	if !r.Succeeded() {
+		return false
+	}
+
+	connected, ok := r.Value().(*value.Bool)
+
+	return ok && connected.Value
+}
+
+// checkOverheated returns ErrOverheated if the barrel is overheated, taking
+// the current overheat policy into account.
+func (g *Gun) checkOverheated() error {
+	g.om.Lock()
+	overheated, cooled := g.overheated, g.cooled
+	g.om.Unlock()
+
+	if !overheated {
+		return nil
+	}
+
+	if g.OverheatPolicy() == OverheatPolicyReject {
+		return ErrOverheated
+	}
+
+	select {
+	case <-cooled:
+		return nil
+	case <-time.After(overheatQueueTimeout):
+		return ErrOverheated
+	}
+}
+
+func (g *Gun) shotInterval(typ Type) time.Duration {
+	return time.Second / time.Duration(g.FireRate(typ))
+}
+
+func (g *Gun) onGunCoolDown(r *result.Result) {
+	if r == nil || !r.Succeeded() {
+		return
+	}
+
+	v, ok := r.Value().(*value.Bool)
+	if !ok {
+		g.l.Error("Unexpected gun cool down value.", "value", r.Value())
+		return
+	}
+
+	g.om.Lock()
+	defer g.om.Unlock()
+
+	if v.Value && !g.overheated {
+		g.l.Warn("Barrel overheated.")
+		g.overheated = true
+		g.cooled = make(chan struct{})
+	} else if !v.Value && g.overheated {
+		g.l.Debug("Barrel cooled down.")
+		g.overheated = false
+		close(g.cooled)
+	}
 }
@@ -0,0 +1,306 @@
+package gun
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/robot"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/mock"
+)
+
+// sentActions returns the key and value of all actions sent to the robot
+// with DirectSendKeyValue(), in order.
+func sentActions(t *testing.T, uw *mock.UnityBridge) ([]*key.Key, []uint64) {
+	var ks []*key.Key
+	var vs []uint64
+
+	for _, e := range uw.Sent() {
+		ev := event.NewFromCode(e.Code)
+		if ev.Type() != event.TypePerformAction || e.Tag != 0 {
+			continue
+		}
+
+		k, err := key.FromSubType(ev.SubType())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ks = append(ks, k)
+		vs = append(vs, e.Number)
+	}
+
+	return ks, vs
+}
+
+// sentCount returns the number of events of the given type sent to the robot
+// for the given key.
+func sentCount(uw *mock.UnityBridge, typ event.Type, k *key.Key) int {
+	code := event.NewFromTypeAndSubType(typ, k.SubType()).Code()
+
+	n := 0
+	for _, e := range uw.Sent() {
+		if e.Code == code {
+			n++
+		}
+	}
+
+	return n
+}
+
+// notify notifies key listeners for the given key about the given result.
+func notify(t *testing.T, uw *mock.UnityBridge, r *result.Result) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = uw.GenerateEvent(event.NewFromTypeAndSubType(
+		event.TypeStartListening, r.Key().SubType()).Code(), data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestGun(t *testing.T) (*Gun, *mock.UnityBridge) {
+	uw, ub := mock.NewStartedUnityBridge(t)
+
+	rm, err := robot.New(ub, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := New(ub, nil, nil, rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g, uw
+}
+
+func setOverheated(g *Gun, overheated bool) {
+	g.onGunCoolDown(result.New(nil, 0, 0, "", &value.Bool{Value: overheated}))
+}
+
+func TestSetFireRate(t *testing.T) {
+	g, uw := newTestGun(t)
+
+	for _, rate := range []uint64{0, maxFireRate + 1} {
+		if g.SetFireRate(TypeBead, rate) == nil {
+			t.Errorf("expected error setting fire rate %d", rate)
+		}
+	}
+
+	if g.SetFireRate(Type(100), 1) == nil {
+		t.Error("expected error setting fire rate for invalid type")
+	}
+
+	if sentCount(uw, event.TypeSetValue,
+		key.KeyRobomasterWaterGunShootFrequency) != 0 {
+		t.Errorf("invalid fire rates were sent to the robot")
+	}
+
+	err := g.SetFireRate(TypeBead, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.FireRate(TypeBead) != 8 || g.shotInterval(TypeBead) !=
+		125*time.Millisecond {
+		t.Errorf("got fire rate %d (interval %s), want 8 (125ms)",
+			g.FireRate(TypeBead), g.shotInterval(TypeBead))
+	}
+
+	if g.FireRate(TypeInfrared) != defaultFireRate {
+		t.Errorf("got infrared fire rate %d, want %d",
+			g.FireRate(TypeInfrared), defaultFireRate)
+	}
+}
+
+func TestOverheatPolicy(t *testing.T) {
+	g, _ := newTestGun(t)
+
+	if g.SetOverheatPolicy(overheatPolicyCount) == nil {
+		t.Error("expected error setting invalid overheat policy")
+	}
+
+	setOverheated(g, true)
+
+	if !g.IsOverheated() {
+		t.Fatal("expected barrel to be overheated")
+	}
+
+	err := g.checkOverheated()
+	if !errors.Is(err, ErrOverheated) {
+		t.Errorf("got error %v, want ErrOverheated", err)
+	}
+
+	err = g.SetOverheatPolicy(OverheatPolicyQueue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errC := make(chan error)
+	go func() {
+		errC <- g.checkOverheated()
+	}()
+
+	select {
+	case err := <-errC:
+		t.Fatalf("firing was not queued: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	setOverheated(g, false)
+
+	select {
+	case err := <-errC:
+		if err != nil {
+			t.Errorf("got error %v after cooling down", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued firing did not resume after cooling down")
+	}
+}
+
+func TestContinuousFireOverheatReject(t *testing.T) {
+	g, uw := newTestGun(t)
+
+	err := g.SetFireRate(TypeBead, maxFireRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = g.startContinuousFire(TypeBead)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if sentCount(uw, event.TypePerformAction,
+		key.KeyRobomasterWaterGunWaterGunFireWithTimes) == 0 {
+		t.Error("continuous fire did not fire")
+	}
+
+	setOverheated(g, true)
+
+	// The loop stops on its own and must clear its state.
+	deadline := time.Now().Add(time.Second)
+	for {
+		g.m.Lock()
+		started := g.continuousQ != nil
+		g.m.Unlock()
+
+		if !started {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("continuous fire state not cleared after overheating")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if g.StopContinuousFire() == nil {
+		t.Error("expected error stopping continuous fire that is not started")
+	}
+
+	setOverheated(g, false)
+
+	err = g.startContinuousFire(TypeBead)
+	if err != nil {
+		t.Fatalf("restarting continuous fire failed: %v", err)
+	}
+
+	err = g.StopContinuousFire()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFireInfraredOverlappingBursts(t *testing.T) {
+	g, uw := newTestGun(t)
+
+	err := g.fireInfrared(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+
+	err = g.fireInfrared(100 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first burst would have ended by now.
+	time.Sleep(50 * time.Millisecond)
+
+	if _, vs := sentActions(t, uw); vs[len(vs)-1] != 1 {
+		t.Fatal("second burst was cut short by the first one")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, vs := sentActions(t, uw)
+	if vs[len(vs)-1] != 0 {
+		t.Fatal("infrared firing not disabled after the bursts")
+	}
+
+	if len(vs) != 3 {
+		t.Errorf("got %d values sent, want 3", len(vs))
+	}
+}
+
+func TestFireInfraredKey(t *testing.T) {
+	g, uw := newTestGun(t)
+
+	err := g.fireInfrared(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Connect the infrared gun.
+	err = g.infraredConnectionRL.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notify(t, uw, result.New(key.KeyRobomasterInfraredGunConnection, 0, 0,
+		"", &value.Bool{Value: true}))
+
+	time.Sleep(10 * time.Millisecond)
+
+	err = g.fireInfrared(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	ks, _ := sentActions(t, uw)
+
+	want := []*key.Key{
+		key.KeyRobomasterWaterGunWaterGunFire,
+		key.KeyRobomasterWaterGunWaterGunFire,
+		key.KeyRobomasterInfraredGunInfraredGunFire,
+		key.KeyRobomasterInfraredGunInfraredGunFire,
+	}
+
+	if len(ks) != len(want) {
+		t.Fatalf("got %d keys sent, want %d", len(ks), len(want))
+	}
+
+	for i, k := range want {
+		if ks[i] != k {
+			t.Errorf("key %d: got %s, want %s", i, ks[i], k)
+		}
+	}
+}
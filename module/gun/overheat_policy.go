@@ -0,0 +1,32 @@
+package gun
+
+import "fmt"
+
+// OverheatPolicy controls what happens when trying to fire while the barrel is
+// overheated.
+type OverheatPolicy uint8
+
+const (
+	// OverheatPolicyReject makes firing fail with ErrOverheated.
+	OverheatPolicyReject OverheatPolicy = iota
+	// OverheatPolicyQueue makes firing wait until the barrel cools down.
+	OverheatPolicyQueue
+	// overheatPolicyCount is the number of policies. Intentionaly not
+	// exported.
+	overheatPolicyCount
+)
+
+func (o OverheatPolicy) String() string {
+	switch o {
+	case OverheatPolicyReject:
+		return "Reject"
+	case OverheatPolicyQueue:
+		return "Queue"
+	default:
+		return fmt.Sprintf("Unknown(%d)", o)
+	}
+}
+
+func (o OverheatPolicy) Valid() bool {
+	return o < overheatPolicyCount
+}
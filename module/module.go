@@ -1,10 +1,15 @@
 package module
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrConnectionNotEstablished is returned when a module connection could not
+// be established.
+var ErrConnectionNotEstablished = errors.New("connection not established")
+
 // Module is the interface implemented by all modules.
 type Module interface {
 	fmt.Stringer
@@ -0,0 +1,50 @@
+package sdcard
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrLocalAlbumNotSupported is returned by all local album methods.
+//
+// The robot exposes the local album (photos and videos stored in the SD card)
+// through event.TypeLocalAlbum events, but their sub-types and payloads are not
+// known yet. The methods below define the intended API and will be implemented
+// once the protocol is figured out (see the TODO file).
+var ErrLocalAlbumNotSupported = errors.New("local album not supported yet")
+
+// Media is a photo or video stored in the SD card.
+type Media struct {
+	// Name is the media file name.
+	Name string
+
+	// Video is true for videos and false for photos.
+	Video bool
+
+	// Size is the media file size in bytes.
+	Size uint64
+
+	// Time is the time the media was captured.
+	Time time.Time
+}
+
+// ListMedia returns the photos and videos stored in the SD card. Not
+// implemented yet (always returns ErrLocalAlbumNotSupported).
+func (m *Module) ListMedia() ([]Media, error) {
+	return nil, ErrLocalAlbumNotSupported
+}
+
+// DownloadMedia downloads the given media to the given local directory,
+// resuming any previous partial download of it. If progress is non-nil, it is
+// called with the number of bytes downloaded so far and the total media size.
+// Not implemented yet (always returns ErrLocalAlbumNotSupported).
+func (m *Module) DownloadMedia(media Media, dir string,
+	progress func(downloaded, total uint64)) error {
+	return ErrLocalAlbumNotSupported
+}
+
+// DeleteMedia deletes the given media from the SD card. Not implemented yet
+// (always returns ErrLocalAlbumNotSupported).
+func (m *Module) DeleteMedia(media Media) error {
+	return ErrLocalAlbumNotSupported
+}
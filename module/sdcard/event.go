@@ -0,0 +1,166 @@
+package sdcard
+
+import (
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+)
+
+// EventType is the type of an SD card state change event.
+type EventType uint8
+
+const (
+	EventTypeInserted EventType = iota
+	EventTypeRemoved
+	EventTypeFull
+	EventTypeNotFull
+	EventTypeLowSpace
+	EventTypeError
+	EventTypeErrorCleared
+	EventTypeFormatStarted
+	EventTypeFormatFinished
+	EventTypeCount
+)
+
+// String returns the event type as a string.
+func (et EventType) String() string {
+	switch et {
+	case EventTypeInserted:
+		return "Inserted"
+	case EventTypeRemoved:
+		return "Removed"
+	case EventTypeFull:
+		return "Full"
+	case EventTypeNotFull:
+		return "NotFull"
+	case EventTypeLowSpace:
+		return "LowSpace"
+	case EventTypeError:
+		return "Error"
+	case EventTypeErrorCleared:
+		return "ErrorCleared"
+	case EventTypeFormatStarted:
+		return "FormatStarted"
+	case EventTypeFormatFinished:
+		return "FormatFinished"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the event type is valid.
+func (et EventType) Valid() bool {
+	return et < EventTypeCount
+}
+
+// Event is an SD card state change event.
+type Event struct {
+	Type EventType
+
+	// Status is the SD card status right after the change.
+	Status Status
+
+	// Time is the time the change was detected.
+	Time time.Time
+}
+
+// EventCallback is the type of the callback function used to receive SD card
+// events.
+type EventCallback func(e Event)
+
+// Status is the SD card status.
+type Status struct {
+	Inserted   bool
+	Formatting bool
+	Full       bool
+	HasError   bool
+
+	TotalSpaceInMB                  uint64
+	RemainingSpaceInMB              uint64
+	AvailablePhotoCount             uint64
+	AvailableRecordingTimeInSeconds uint64
+}
+
+// statusTracker keeps track of the SD card status and generates events for
+// state changes.
+type statusTracker struct {
+	status Status
+
+	// lowSpaceInMB is the remaining space threshold under which the space is
+	// considered low.
+	lowSpaceInMB uint64
+	lowSpace     bool
+
+	// Whether the total and remaining space were received since the SD card
+	// was inserted. Low space can only be detected when both are known.
+	totalSpaceKnown     bool
+	remainingSpaceKnown bool
+}
+
+// update applies the given value for the given key to the tracked status and
+// returns the events it generated (if any). Returns false if the key is not a
+// status key or the value is of an unexpected type.
+func (st *statusTracker) update(k *key.Key, v any) ([]EventType, bool) {
+	update, ok := statusUpdaters[k]
+	if !ok {
+		return nil, false
+	}
+
+	old := st.status
+	if !update(&st.status, v) {
+		return nil, false
+	}
+	s := st.status
+
+	switch k {
+	case key.KeyCameraSDCardTotalSpaceInMB:
+		st.totalSpaceKnown = true
+	case key.KeyCameraSDCardRemainingSpaceInMB:
+		st.remainingSpaceKnown = true
+	}
+
+	var events []EventType
+
+	if s.Inserted != old.Inserted {
+		events = append(events, pick(s.Inserted, EventTypeInserted,
+			EventTypeRemoved))
+	}
+
+	if s.Full != old.Full {
+		events = append(events, pick(s.Full, EventTypeFull,
+			EventTypeNotFull))
+	}
+
+	if s.HasError != old.HasError {
+		events = append(events, pick(s.HasError, EventTypeError,
+			EventTypeErrorCleared))
+	}
+
+	if s.Formatting != old.Formatting {
+		events = append(events, pick(s.Formatting, EventTypeFormatStarted,
+			EventTypeFormatFinished))
+	}
+
+	if !s.Inserted && old.Inserted {
+		// Space must be received again for the next SD card.
+		st.totalSpaceKnown = false
+		st.remainingSpaceKnown = false
+	}
+
+	lowSpace := s.Inserted && st.totalSpaceKnown && st.remainingSpaceKnown &&
+		s.TotalSpaceInMB > 0 && s.RemainingSpaceInMB < st.lowSpaceInMB
+	if lowSpace && !st.lowSpace {
+		events = append(events, EventTypeLowSpace)
+	}
+	st.lowSpace = lowSpace
+
+	return events, true
+}
+
+func pick(cond bool, ifTrue, ifFalse EventType) EventType {
+	if cond {
+		return ifTrue
+	}
+
+	return ifFalse
+}
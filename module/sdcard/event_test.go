@@ -0,0 +1,64 @@
+package sdcard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+func TestStatusTrackerEvents(t *testing.T) {
+	st := statusTracker{lowSpaceInMB: 100}
+
+	inserted := key.KeyCameraSDCardIsInserted
+	formatting := key.KeyCameraSDCardIsFormatting
+	full := key.KeyCameraSDCardIsFull
+	hasError := key.KeyCameraSDCardHasError
+	total := key.KeyCameraSDCardTotalSpaceInMB
+	remaining := key.KeyCameraSDCardRemainingSpaceInMB
+
+	tests := []struct {
+		k    *key.Key
+		v    any
+		want []EventType
+	}{
+		{inserted, &value.Bool{Value: true}, []EventType{EventTypeInserted}},
+		// Remaining space is not known yet, so this is not low space.
+		{total, &value.Uint64{Value: 1000}, nil},
+		{remaining, &value.Uint64{Value: 500}, nil},
+		{remaining, &value.Uint64{Value: 50}, []EventType{EventTypeLowSpace}},
+		{remaining, &value.Uint64{Value: 40}, nil},
+		{full, &value.Bool{Value: true}, []EventType{EventTypeFull}},
+		{formatting, &value.Bool{Value: true}, []EventType{EventTypeFormatStarted}},
+		{formatting, &value.Bool{Value: false}, []EventType{EventTypeFormatFinished}},
+		{full, &value.Bool{Value: false}, []EventType{EventTypeNotFull}},
+		{remaining, &value.Uint64{Value: 1000}, nil},
+		{hasError, &value.Bool{Value: true}, []EventType{EventTypeError}},
+		{hasError, &value.Bool{Value: false}, []EventType{EventTypeErrorCleared}},
+		{inserted, &value.Bool{Value: false}, []EventType{EventTypeRemoved}},
+		// New SD card. Total space is not known yet.
+		{inserted, &value.Bool{Value: true}, []EventType{EventTypeInserted}},
+		{remaining, &value.Uint64{Value: 50}, nil},
+		{total, &value.Uint64{Value: 1000}, []EventType{EventTypeLowSpace}},
+	}
+
+	for i, test := range tests {
+		got, ok := st.update(test.k, test.v)
+		if !ok {
+			t.Fatalf("step %d: update failed", i)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("step %d: got events %v, want %v", i, got, test.want)
+		}
+	}
+
+	if _, ok := st.update(total, &value.Bool{Value: true}); ok {
+		t.Error("expected update with unexpected value type to fail")
+	}
+
+	if _, ok := st.update(key.KeyCameraMode, &value.Uint64{}); ok {
+		t.Error("expected update for unknown key to fail")
+	}
+}
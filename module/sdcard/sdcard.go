@@ -3,19 +3,35 @@ package sdcard
 import (
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/brunoga/robomaster/module/camera"
 	"github.com/brunoga/robomaster/module/connection"
 	"github.com/brunoga/robomaster/module/internal"
+	"github.com/brunoga/robomaster/support/delivery"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
 )
 
+// defaultLowSpaceInMB is the default remaining space threshold under which an
+// EventTypeLowSpace event is generated.
+const defaultLowSpaceInMB = 100
+
+// Module provides support for managing the SD card inserted in the robot
+// camera.
 type Module struct {
 	*internal.BaseModule
+
+	m         sync.Mutex
+	st        statusTracker
+	keyTokens map[*key.Key]token.Token
+
+	callbacks *delivery.Callbacks[Event]
 }
 
 // New creates a new SDCard module instance.
@@ -27,7 +43,11 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 
 	l = l.WithGroup("sdcard_module")
 
-	m := &Module{}
+	m := &Module{
+		st:        statusTracker{lowSpaceInMB: defaultLowSpaceInMB},
+		keyTokens: make(map[*key.Key]token.Token),
+		callbacks: delivery.NewCallbacks[Event](),
+	}
 
 	m.BaseModule = internal.NewBaseModule(ub, l, "SDCard", nil, func(r *result.Result) {
 		if !r.Succeeded() {
@@ -51,6 +71,104 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 	return m, nil
 }
 
+// Start starts the SD card module.
+func (m *Module) Start() error {
+	for k := range statusUpdaters {
+		k := k
+		t, err := m.UB().AddKeyListener(k, func(r *result.Result) {
+			m.onStatusUpdate(k, r)
+		}, true)
+		if err != nil {
+			m.removeKeyListeners()
+			return err
+		}
+
+		m.m.Lock()
+		m.keyTokens[k] = t
+		m.m.Unlock()
+	}
+
+	err := m.BaseModule.Start()
+	if err != nil {
+		m.removeKeyListeners()
+		return err
+	}
+
+	return nil
+}
+
+// Status returns the last known SD card status. It is kept up to date by the
+// robot so, unlike the individual getters, it does not query the robot.
+func (m *Module) Status() Status {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	return m.st.status
+}
+
+// SetLowSpaceThreshold sets the remaining space (in MB) under which an
+// EventTypeLowSpace event is generated. This can be used to, for example, stop
+// recording gracefully before the SD card fills up.
+func (m *Module) SetLowSpaceThreshold(mb uint64) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	m.st.lowSpaceInMB = mb
+}
+
+// AddEventCallback adds a callback function to be called when the SD card
+// state changes. The callback function will be called in a separate
+// goroutine, with events delivered in the order they happened. Returns a token
+// that can be used to remove the callback later.
+func (m *Module) AddEventCallback(cb EventCallback) (token.Token, error) {
+	if cb == nil {
+		return 0, fmt.Errorf("callback must not be nil")
+	}
+
+	return m.callbacks.Add(cb), nil
+}
+
+// RemoveEventCallback removes the event callback associated with the given
+// token. Events not yet delivered to the callback are dropped and it is not
+// called again once this returns.
+func (m *Module) RemoveEventCallback(t token.Token) error {
+	if !m.callbacks.Remove(t) {
+		return fmt.Errorf("no event callback added for token %d", t)
+	}
+
+	return nil
+}
+
+// Stop stops the SD card module.
+func (m *Module) Stop() error {
+	err := m.removeKeyListeners()
+	if err != nil {
+		return err
+	}
+
+	return m.BaseModule.Stop()
+}
+
+// removeKeyListeners removes all status key listeners added by Start. All
+// listeners are removed even if removing one of them fails, in which case the
+// first error is returned.
+func (m *Module) removeKeyListeners() error {
+	m.m.Lock()
+	keyTokens := m.keyTokens
+	m.keyTokens = make(map[*key.Key]token.Token)
+	m.m.Unlock()
+
+	var firstErr error
+	for k, t := range keyTokens {
+		err := m.UB().RemoveKeyListener(k, t)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
 func (m *Module) IsInserted() (bool, error) {
 	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardIsInserted, false)
 	if err != nil {
@@ -78,6 +196,78 @@ func (m *Module) Format() error {
 	return nil
 }
 
+// FormatSync formats the SD card and waits for formatting to complete (or for
+// the given timeout to expire). The robot does not report formatting progress
+// so, if tick is non-nil, it is just called every 500ms with the time elapsed
+// since formatting was requested (to show formatting is still going on, for
+// example).
+func (m *Module) FormatSync(timeout time.Duration,
+	tick func(elapsed time.Duration)) error {
+	formattingChan := make(chan bool, 4)
+
+	t, err := m.AddEventCallback(func(e Event) {
+		if e.Type != EventTypeFormatStarted &&
+			e.Type != EventTypeFormatFinished {
+			return
+		}
+
+		// Never block as we might not be waiting anymore.
+		select {
+		case formattingChan <- e.Type == EventTypeFormatStarted:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer m.RemoveEventCallback(t)
+
+	err = m.Format()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	started := false
+	for {
+		select {
+		case formatting := <-formattingChan:
+			if formatting {
+				started = true
+			} else if started {
+				return m.checkFormatResult()
+			}
+		case <-ticker.C:
+			if tick != nil {
+				tick(time.Since(start))
+			}
+
+			// In case we missed the formatting started event.
+			if !started && time.Since(start) > 2*time.Second {
+				formatting, err := m.IsFormatting()
+				if err != nil {
+					return err
+				}
+
+				if !formatting {
+					return m.checkFormatResult()
+				}
+
+				started = true
+			}
+		case <-timer.C:
+			return fmt.Errorf("timeout waiting for SD card format to finish")
+		}
+	}
+}
+
 func (m *Module) IsFormatting() (bool, error) {
 	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardIsFormatting, false)
 	if err != nil {
@@ -203,3 +393,100 @@ func (m *Module) AvailableRecordingTimeInSeconds() (uint64, error) {
 
 	return v.Value, nil
 }
+
+func (m *Module) checkFormatResult() error {
+	hasError, err := m.HasError()
+	if err != nil {
+		return err
+	}
+
+	if hasError {
+		return fmt.Errorf("SD card has errors after format")
+	}
+
+	return nil
+}
+
+// statusUpdaters maps SD card keys to functions that update the status with
+// their values.
+var statusUpdaters = map[*key.Key]func(s *Status, v any) bool{
+	key.KeyCameraSDCardIsInserted: boolUpdater(func(s *Status) *bool {
+		return &s.Inserted
+	}),
+	key.KeyCameraSDCardIsFormatting: boolUpdater(func(s *Status) *bool {
+		return &s.Formatting
+	}),
+	key.KeyCameraSDCardIsFull: boolUpdater(func(s *Status) *bool {
+		return &s.Full
+	}),
+	key.KeyCameraSDCardHasError: boolUpdater(func(s *Status) *bool {
+		return &s.HasError
+	}),
+	key.KeyCameraSDCardTotalSpaceInMB: uint64Updater(func(s *Status) *uint64 {
+		return &s.TotalSpaceInMB
+	}),
+	key.KeyCameraSDCardRemainingSpaceInMB: uint64Updater(func(s *Status) *uint64 {
+		return &s.RemainingSpaceInMB
+	}),
+	key.KeyCameraSDCardAvailablePhotoCount: uint64Updater(func(s *Status) *uint64 {
+		return &s.AvailablePhotoCount
+	}),
+	key.KeyCameraSDCardAvailableRecordingTimeInSeconds: uint64Updater(func(s *Status) *uint64 {
+		return &s.AvailableRecordingTimeInSeconds
+	}),
+}
+
+func boolUpdater(field func(s *Status) *bool) func(s *Status, v any) bool {
+	return func(s *Status, v any) bool {
+		b, ok := v.(*value.Bool)
+		if ok {
+			*field(s) = b.Value
+		}
+
+		return ok
+	}
+}
+
+func uint64Updater(field func(s *Status) *uint64) func(s *Status, v any) bool {
+	return func(s *Status, v any) bool {
+		u, ok := v.(*value.Uint64)
+		if ok {
+			*field(s) = u.Value
+		}
+
+		return ok
+	}
+}
+
+func (m *Module) onStatusUpdate(k *key.Key, r *result.Result) {
+	if !r.Succeeded() {
+		m.Logger().Error("Status update: Unsuccessfull result.", "result", r)
+		return
+	}
+
+	// Events are queued with m held so concurrent updates for different keys
+	// can not reorder them (FormatSync relies on FormatStarted arriving before
+	// FormatFinished, for example).
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	events, ok := m.st.update(k, r.Value())
+	if !ok {
+		m.Logger().Error("Status update: Unexpected value.", "key", k,
+			"value", r.Value())
+		return
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	status := m.st.status
+	now := time.Now()
+
+	for _, et := range events {
+		m.Logger().Debug("SD card event.", "type", et)
+
+		m.callbacks.Push(Event{Type: et, Status: status, Time: now})
+	}
+}
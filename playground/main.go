@@ -8,6 +8,7 @@ import (
 
 	"github.com/brunoga/net/client"
 	"github.com/brunoga/net/server"
+	"github.com/brunoga/robomaster/support/duml"
 	"github.com/brunoga/robomaster/support/finder"
 )
 
@@ -47,10 +48,10 @@ func connectFromPort10608ToPort10607(robotIP net.IP) *client.Client {
 	var c *client.Client
 
 	c, err = client.NewWithConn(conn, client.ScanFullBuffer, func(data []byte) {
-		if n := bytes.Index(data, []byte{0x55}); n != -1 {
-			m, err := NewMessage(data[n:])
+		if n := bytes.IndexByte(data, duml.Magic); n != -1 {
+			m, _, err := duml.Decode(data[n:])
 			if err == nil {
-				fmt.Printf("** Message: MsgSet:%02x MsgID:%02x\n", m.ProtoCmdSet(), m.ProtoCmdID())
+				fmt.Printf("** Message: MsgSet:%s MsgID:%02x\n", m.CmdSet, m.CmdID)
 				return
 			}
 			fmt.Println("Received packet with no message")
@@ -136,7 +137,7 @@ func main() {
 	// byte is 0x10 (0b10000) and the next one is 0x04 (0b00000100). According
 	// to the code pointed above, this encodes a size value of 0x10 (16) bytes,
 	// which matches the data size from the 55 value (inclusive) on. The next
-	// byte is a crc (we already have the code for that TODO(bga): add it here).
+	// byte is a crc (see the support/duml package).
 	// The next 2 bytes are sender and receiver. The next one is the lower 8
 	// bits of the sequence id, followed by the upper 8 bits. The an attribute
 	// byte (is_ack, need_ack, enc). Then if there is a proto associated with
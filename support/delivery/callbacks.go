@@ -0,0 +1,88 @@
+package delivery
+
+import (
+	"sync"
+
+	"github.com/brunoga/robomaster/support/token"
+)
+
+// Callbacks is a set of callbacks identified by tokens. Each callback gets its
+// own Queue, so values are delivered to each of them in order and a slow
+// callback does not delay the others. The zero value is not valid. Use
+// NewCallbacks.
+type Callbacks[T any] struct {
+	tg *token.Generator
+
+	m      sync.Mutex
+	queues map[token.Token]*Queue[T]
+}
+
+// NewCallbacks creates a new, empty, Callbacks set.
+func NewCallbacks[T any]() *Callbacks[T] {
+	return &Callbacks[T]{
+		tg:     token.NewGenerator(),
+		queues: make(map[token.Token]*Queue[T]),
+	}
+}
+
+// Add adds the given callback to the set and returns a token that can be used
+// to remove it.
+func (c *Callbacks[T]) Add(cb func(T)) token.Token {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	t := c.tg.Next()
+
+	c.queues[t] = NewQueue(cb)
+
+	return t
+}
+
+// Remove removes the callback associated with the given token, closing its
+// queue (see Queue.Close). Returns false if there is no such callback.
+func (c *Callbacks[T]) Remove(t token.Token) bool {
+	c.m.Lock()
+	q, ok := c.queues[t]
+	delete(c.queues, t)
+	c.m.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	// The callback might be calling into the set, so the lock must not be
+	// held here.
+	q.Close()
+
+	return true
+}
+
+// Push queues the given value for delivery to all callbacks in the set.
+func (c *Callbacks[T]) Push(v T) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for _, q := range c.queues {
+		q.Push(v)
+	}
+}
+
+// Len returns the number of callbacks in the set.
+func (c *Callbacks[T]) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return len(c.queues)
+}
+
+// Clear removes all callbacks from the set, closing their queues.
+func (c *Callbacks[T]) Clear() {
+	c.m.Lock()
+	queues := c.queues
+	c.queues = make(map[token.Token]*Queue[T])
+	c.m.Unlock()
+
+	for _, q := range queues {
+		q.Close()
+	}
+}
@@ -0,0 +1,91 @@
+// Package delivery provides helpers for delivering values to callbacks in
+// order from a dedicated goroutine, so slow callbacks do not block producers
+// and callbacks never see values out of order.
+package delivery
+
+import "sync"
+
+// Queue delivers values to a callback in the order they were pushed. The
+// callback is called from a single goroutine owned by the queue. The queue is
+// unbounded, so callbacks that are consistently slower than producers will
+// make it grow.
+type Queue[T any] struct {
+	cb func(T)
+
+	m      sync.Mutex
+	values []T
+	closed bool
+
+	signal chan struct{}
+	w      *Worker
+}
+
+// NewQueue creates a new Queue that delivers values to the given callback.
+func NewQueue[T any](cb func(T)) *Queue[T] {
+	q := &Queue[T]{
+		cb:     cb,
+		signal: make(chan struct{}, 1),
+	}
+
+	q.w = Go(q.loop)
+
+	return q
+}
+
+// Push queues the given value for delivery. It never blocks. Values pushed
+// after the queue is closed are discarded.
+func (q *Queue[T]) Push(v T) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.values = append(q.values, v)
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Close closes the queue, discarding any values not delivered yet. The
+// callback is not called again after Close returns. If the callback is not
+// running, Close also waits for the queue goroutine to exit. If it is running
+// (for example, because Close was called from the callback itself), Close
+// returns without waiting for it.
+func (q *Queue[T]) Close() {
+	q.m.Lock()
+	if !q.closed {
+		q.closed = true
+		q.values = nil
+		close(q.signal)
+	}
+	q.m.Unlock()
+
+	q.w.Wait()
+}
+
+func (q *Queue[T]) loop(w *Worker) {
+	for range q.signal {
+		for {
+			q.m.Lock()
+			if q.closed || len(q.values) == 0 {
+				q.m.Unlock()
+				break
+			}
+
+			v := q.values[0]
+
+			var zero T
+			q.values[0] = zero
+			q.values = q.values[1:]
+			q.m.Unlock()
+
+			w.Call(func() {
+				q.cb(v)
+			})
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package delivery
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueueOrder(t *testing.T) {
+	got := make(chan int, 100)
+
+	q := NewQueue(func(v int) {
+		if v%10 == 0 {
+			// Slow callback. Values must still arrive in order.
+			time.Sleep(time.Millisecond)
+		}
+
+		got <- v
+	})
+	defer q.Close()
+
+	for i := 0; i < 100; i++ {
+		q.Push(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		select {
+		case v := <-got:
+			if v != i {
+				t.Fatalf("got value %d, want %d", v, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for value %d", i)
+		}
+	}
+}
+
+func TestQueueCloseWhileCallbackRunning(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	q := NewQueue(func(v int) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+	})
+
+	q.Push(1)
+	q.Push(2)
+
+	<-started
+
+	// The callback is running, so this must not wait for it.
+	q.Close()
+
+	close(release)
+
+	time.Sleep(50 * time.Millisecond)
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d callback calls, want 1", n)
+	}
+
+	// No-op after closing.
+	q.Push(3)
+	q.Close()
+}
+
+func TestQueueCloseFromCallback(t *testing.T) {
+	done := make(chan struct{})
+
+	var q *Queue[int]
+	q = NewQueue(func(v int) {
+		q.Close()
+		close(done)
+	})
+
+	q.Push(1)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close called from the callback did not return")
+	}
+
+	q.Close()
+}
+
+func TestCallbacks(t *testing.T) {
+	c := NewCallbacks[int]()
+
+	got1 := make(chan int, 10)
+	got2 := make(chan int, 10)
+
+	t1 := c.Add(func(v int) { got1 <- v })
+	t2 := c.Add(func(v int) { got2 <- v })
+
+	if c.Len() != 2 {
+		t.Fatalf("got %d callbacks, want 2", c.Len())
+	}
+
+	c.Push(1)
+
+	for _, got := range []chan int{got1, got2} {
+		select {
+		case v := <-got:
+			if v != 1 {
+				t.Errorf("got value %d, want 1", v)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for value")
+		}
+	}
+
+	if !c.Remove(t1) {
+		t.Error("callback not found")
+	}
+
+	if c.Remove(t1) {
+		t.Error("callback removed twice")
+	}
+
+	c.Push(2)
+
+	select {
+	case v := <-got1:
+		t.Errorf("got value %d after removal", v)
+	case v := <-got2:
+		if v != 2 {
+			t.Errorf("got value %d, want 2", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for value")
+	}
+
+	c.Clear()
+
+	if c.Len() != 0 || c.Remove(t2) {
+		t.Error("callbacks not cleared")
+	}
+}
@@ -0,0 +1,48 @@
+package delivery
+
+import "sync/atomic"
+
+// Worker runs a function in its own goroutine and allows waiting for it to
+// return. The function must call any callbacks through the Worker (see Call),
+// so waiting is not attempted while one of them is running.
+type Worker struct {
+	inCallback atomic.Bool
+	done       chan struct{}
+}
+
+// Go runs the given function in a new goroutine and returns a Worker that can
+// be used to wait for it to return. The function gets the Worker itself so it
+// can call callbacks through it.
+func Go(fn func(w *Worker)) *Worker {
+	w := &Worker{
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(w.done)
+
+		fn(w)
+	}()
+
+	return w
+}
+
+// Call calls the given callback, flagging it as running while it does.
+func (w *Worker) Call(cb func()) {
+	w.inCallback.Store(true)
+	defer w.inCallback.Store(false)
+
+	cb()
+}
+
+// Wait waits for the worker function to return. If a callback is running (see
+// Call), it returns immediately instead, as the callback might be the one
+// calling Wait and waiting would never complete. In that case, the callback is
+// still running when Wait returns.
+func (w *Worker) Wait() {
+	if w.inCallback.Load() {
+		return
+	}
+
+	<-w.done
+}
@@ -0,0 +1,93 @@
+package duml
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// CmdSet is a message command set. Command sets group related commands (the
+// CmdID in a message).
+type CmdSet byte
+
+// Known command sets (see the Robomaster SDK protocol definitions).
+const (
+	CmdSetCommon     CmdSet = 0x00
+	CmdSetCamera     CmdSet = 0x02
+	CmdSetGimbal     CmdSet = 0x04
+	CmdSetVision     CmdSet = 0x0a
+	CmdSetRoboMaster CmdSet = 0x3f
+	CmdSetSDK        CmdSet = 0x48
+)
+
+var (
+	cmdSetsM sync.RWMutex
+	cmdSets  = map[CmdSet]string{
+		CmdSetCommon:     "Common",
+		CmdSetCamera:     "Camera",
+		CmdSetGimbal:     "Gimbal",
+		CmdSetVision:     "Vision",
+		CmdSetRoboMaster: "RoboMaster",
+		CmdSetSDK:        "SDK",
+	}
+)
+
+// RegisterCmdSet registers a name for the given command set. This is useful
+// as command sets are reverse engineered. It returns a non-nil error if the
+// command set is already registered.
+func RegisterCmdSet(cs CmdSet, name string) error {
+	if name == "" {
+		return fmt.Errorf("command set name must be non-empty")
+	}
+
+	cmdSetsM.Lock()
+	defer cmdSetsM.Unlock()
+
+	if existing, ok := cmdSets[cs]; ok {
+		return fmt.Errorf("command set %02x already registered as %q",
+			byte(cs), existing)
+	}
+
+	cmdSets[cs] = name
+
+	return nil
+}
+
+// CmdSets returns all registered command sets, in ascending order.
+func CmdSets() []CmdSet {
+	cmdSetsM.RLock()
+	defer cmdSetsM.RUnlock()
+
+	css := make([]CmdSet, 0, len(cmdSets))
+	for cs := range cmdSets {
+		css = append(css, cs)
+	}
+
+	sort.Slice(css, func(i, j int) bool {
+		return css[i] < css[j]
+	})
+
+	return css
+}
+
+// Known returns true if the command set is registered.
+func (cs CmdSet) Known() bool {
+	cmdSetsM.RLock()
+	defer cmdSetsM.RUnlock()
+
+	_, ok := cmdSets[cs]
+
+	return ok
+}
+
+// String returns the command set name, if registered, or its hex value.
+func (cs CmdSet) String() string {
+	cmdSetsM.RLock()
+	defer cmdSetsM.RUnlock()
+
+	if name, ok := cmdSets[cs]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("CmdSet(%02x)", byte(cs))
+}
@@ -0,0 +1,177 @@
+package duml
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// Magic is the first byte of every message.
+	Magic = 0x55
+
+	// Version is the only supported protocol version.
+	Version = 1
+
+	// headerLen is the length of the magic, length/version and crc8 bytes.
+	headerLen = 4
+
+	// MinLen is the length of a message with no payload (header + sender +
+	// receiver + sequence + attributes + cmdset + cmdid + crc16).
+	MinLen = headerLen + 7 + 2
+
+	// MaxLen is the maximum message length (it is encoded with 10 bits).
+	MaxLen = 0x3ff
+
+	// MaxPayloadLen is the maximum payload length.
+	MaxPayloadLen = MaxLen - MinLen
+)
+
+// ErrShortMessage is returned when there is not enough data for a full
+// message.
+var ErrShortMessage = errors.New("short message")
+
+// Attrs are message attributes.
+type Attrs byte
+
+const (
+	AttrNeedsAck Attrs = 0b00010000
+	AttrIsAck    Attrs = 0b01000000
+)
+
+// IsAck returns true if the message is an ACK to a previous message.
+func (a Attrs) IsAck() bool {
+	return a&AttrIsAck != 0
+}
+
+// NeedsAck returns true if the message must be ACKed by the receiver.
+func (a Attrs) NeedsAck() bool {
+	return a&AttrNeedsAck != 0
+}
+
+// Message is a DUML message (a 0x55 frame) as exchanged between the app and
+// the robot.
+type Message struct {
+	Sender   byte
+	Receiver byte
+	Sequence uint16
+	Attrs    Attrs
+	CmdSet   CmdSet
+	CmdID    byte
+	Payload  []byte
+}
+
+// Len returns the length of the encoded message.
+func (m *Message) Len() int {
+	return MinLen + len(m.Payload)
+}
+
+// String returns a string representation of the message.
+func (m *Message) String() string {
+	return fmt.Sprintf("Sender:%02x, Receiver:%02x, Sequence:%d, Attrs:%02x, "+
+		"CmdSet:%s, CmdID:%02x, Payload:% x", m.Sender, m.Receiver,
+		m.Sequence, byte(m.Attrs), m.CmdSet, m.CmdID, m.Payload)
+}
+
+// Encode returns the encoded message. It returns a non-nil error if the
+// payload is too big.
+func (m *Message) Encode() ([]byte, error) {
+	if len(m.Payload) > MaxPayloadLen {
+		return nil, fmt.Errorf("payload too big: %d > %d", len(m.Payload),
+			MaxPayloadLen)
+	}
+
+	l := m.Len()
+
+	data := make([]byte, l)
+
+	data[0] = Magic
+	data[1] = byte(l)
+	data[2] = byte(l>>8)&0x3 | Version<<2
+	data[3] = crc8(data[:3])
+	data[4] = m.Sender
+	data[5] = m.Receiver
+	data[6] = byte(m.Sequence)
+	data[7] = byte(m.Sequence >> 8)
+	data[8] = byte(m.Attrs)
+	data[9] = byte(m.CmdSet)
+	data[10] = m.CmdID
+
+	copy(data[11:], m.Payload)
+
+	crc := crc16(data[:l-2])
+	data[l-2] = byte(crc)
+	data[l-1] = byte(crc >> 8)
+
+	return data, nil
+}
+
+// FrameLen validates the header at the start of the given data and returns the
+// length of the message it describes. It returns ErrShortMessage if there is
+// not enough data for the header.
+func FrameLen(data []byte) (int, error) {
+	if len(data) < headerLen {
+		return 0, ErrShortMessage
+	}
+
+	if data[0] != Magic {
+		return 0, fmt.Errorf("invalid message magic byte: %02x", data[0])
+	}
+
+	expectedCrc8 := crc8(data[:3])
+	if expectedCrc8 != data[3] {
+		return 0, fmt.Errorf("invalid message crc8: %02x != %02x",
+			expectedCrc8, data[3])
+	}
+
+	if version := data[2] >> 2; version != Version {
+		return 0, fmt.Errorf("unsupported message version: %d", version)
+	}
+
+	l := int(data[1]) | int(data[2]&0x3)<<8
+	if l < MinLen {
+		return 0, fmt.Errorf("invalid message length: %d", l)
+	}
+
+	return l, nil
+}
+
+// Decode decodes the message at the start of the given data and returns it
+// together with the number of bytes consumed. It returns ErrShortMessage (maybe
+// wrapped) if the data does not contain a full message, in which case the
+// caller can try again with more data. The returned message does not reference
+// the given data.
+func Decode(data []byte) (*Message, int, error) {
+	l, err := FrameLen(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(data) < l {
+		return nil, 0, fmt.Errorf("need %d bytes, got %d: %w", l, len(data),
+			ErrShortMessage)
+	}
+
+	data = data[:l]
+
+	expectedCrc16 := crc16(data[:l-2])
+	actualCrc16 := uint16(data[l-2]) | uint16(data[l-1])<<8
+	if expectedCrc16 != actualCrc16 {
+		return nil, 0, fmt.Errorf("invalid message crc16: %04x != %04x",
+			expectedCrc16, actualCrc16)
+	}
+
+	m := &Message{
+		Sender:   data[4],
+		Receiver: data[5],
+		Sequence: uint16(data[6]) | uint16(data[7])<<8,
+		Attrs:    Attrs(data[8]),
+		CmdSet:   CmdSet(data[9]),
+		CmdID:    data[10],
+	}
+
+	if l > MinLen {
+		m.Payload = append([]byte(nil), data[11:l-2]...)
+	}
+
+	return m, l, nil
+}
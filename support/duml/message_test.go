@@ -0,0 +1,223 @@
+package duml
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var testMessage = []byte{
+	0x55, 0x10, 0x04, 0x56, 0x02, 0x09, 0x2d, 0x27,
+	0x40, 0x3f, 0x77, 0x01, 0x04, 0x01, 0x5b, 0x0e,
+}
+
+func TestDecode(t *testing.T) {
+	m, n, err := Decode(testMessage)
+	if err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+
+	if n != len(testMessage) {
+		t.Fatalf("Decode() consumed %d bytes, want %d", n, len(testMessage))
+	}
+
+	if m.Sender != 0x02 {
+		t.Fatalf("Sender failed: %02x != %02x", m.Sender, 0x02)
+	}
+
+	if m.Receiver != 0x09 {
+		t.Fatalf("Receiver failed: %02x != %02x", m.Receiver, 0x09)
+	}
+
+	if m.Sequence != 10029 {
+		t.Fatalf("Sequence failed: %d != %d", m.Sequence, 10029)
+	}
+
+	if m.Attrs != 0x40 {
+		t.Fatalf("Attrs failed: %02x != %02x", m.Attrs, 0x40)
+	}
+
+	if !m.Attrs.IsAck() {
+		t.Fatalf("Attrs.IsAck() failed: false != true")
+	}
+
+	if m.Attrs.NeedsAck() {
+		t.Fatalf("Attrs.NeedsAck() failed: true != false")
+	}
+
+	if len(m.Payload) != 3 {
+		t.Fatalf("Payload failed: %d != %d", len(m.Payload), 3)
+	}
+
+	if m.CmdSet != CmdSetRoboMaster {
+		t.Fatalf("CmdSet failed: %s != %s", m.CmdSet, CmdSetRoboMaster)
+	}
+
+	if m.CmdID != 0x77 {
+		t.Fatalf("CmdID failed: %02x != %02x", m.CmdID, 0x77)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	m, _, err := Decode(testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := m.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, testMessage) {
+		t.Errorf("got % x, want % x", data, testMessage)
+	}
+
+	m.Payload = make([]byte, MaxPayloadLen)
+
+	data, err = m.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, n, err := Decode(data)
+	if err != nil || n != MaxLen || len(decoded.Payload) != MaxPayloadLen {
+		t.Errorf("max length round trip failed: %d bytes, %v", n, err)
+	}
+
+	m.Payload = make([]byte, MaxPayloadLen+1)
+
+	_, err = m.Encode()
+	if err == nil {
+		t.Error("expected error for payload too big")
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	empty := &Message{Sender: 1, Receiver: 2, CmdSet: CmdSetGimbal}
+
+	data, err := empty.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := append(append([]byte(nil), testMessage...), data...)
+
+	m, n, err := Decode(stream)
+	if err != nil || n != len(testMessage) {
+		t.Fatalf("first message: %d bytes, %v", n, err)
+	}
+
+	m, n, err = Decode(stream[n:])
+	if err != nil || n != MinLen {
+		t.Fatalf("second message: %d bytes, %v", n, err)
+	}
+
+	if m.CmdSet != CmdSetGimbal || m.Payload != nil {
+		t.Errorf("unexpected second message: %s", m)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for i := 0; i < len(testMessage); i++ {
+		_, _, err := Decode(testMessage[:i])
+		if !errors.Is(err, ErrShortMessage) {
+			t.Errorf("truncated to %d bytes: got error %v, want "+
+				"ErrShortMessage", i, err)
+		}
+	}
+
+	for i := 0; i < len(testMessage); i++ {
+		corrupted := append([]byte(nil), testMessage...)
+		corrupted[i] ^= 0x01
+
+		_, _, err := Decode(corrupted)
+		if err == nil {
+			t.Errorf("expected error for corrupted byte %d", i)
+		}
+	}
+}
+
+func TestCmdSetRegistry(t *testing.T) {
+	if CmdSetRoboMaster.String() != "RoboMaster" {
+		t.Errorf("got %s, want RoboMaster", CmdSetRoboMaster)
+	}
+
+	cs := CmdSet(0xfe)
+	if cs.Known() || cs.String() != "CmdSet(fe)" {
+		t.Errorf("unexpected unregistered command set: %s", cs)
+	}
+
+	err := RegisterCmdSet(cs, "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cs.Known() || cs.String() != "Test" {
+		t.Errorf("unexpected registered command set: %s", cs)
+	}
+
+	if RegisterCmdSet(cs, "Test") == nil {
+		t.Error("expected error registering command set twice")
+	}
+
+	css := CmdSets()
+	if css[len(css)-1] != cs {
+		t.Errorf("got command sets %v", css)
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add(testMessage)
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, n, err := Decode(data)
+		if err != nil {
+			return
+		}
+
+		// Anything that decodes must encode back to the same bytes.
+		encoded, err := m.Encode()
+		if err != nil {
+			t.Fatalf("Encode() failed: %v", err)
+		}
+
+		if !bytes.Equal(encoded, data[:n]) {
+			t.Fatalf("got % x, want % x", encoded, data[:n])
+		}
+	})
+}
+
+func FuzzEncode(f *testing.F) {
+	f.Add(byte(0x02), byte(0x09), uint16(10029), byte(0x40), byte(0x3f),
+		byte(0x77), []byte{0x01, 0x04, 0x01})
+
+	f.Fuzz(func(t *testing.T, sender, receiver byte, sequence uint16,
+		attrs, cmdSet, cmdID byte, payload []byte) {
+		if len(payload) > MaxPayloadLen {
+			payload = payload[:MaxPayloadLen]
+		}
+
+		if len(payload) == 0 {
+			payload = nil
+		}
+
+		m := &Message{sender, receiver, sequence, Attrs(attrs),
+			CmdSet(cmdSet), cmdID, payload}
+
+		data, err := m.Encode()
+		if err != nil {
+			t.Fatalf("Encode() failed: %v", err)
+		}
+
+		decoded, n, err := Decode(data)
+		if err != nil {
+			t.Fatalf("Decode() failed: %v", err)
+		}
+
+		if n != len(data) || decoded.String() != m.String() {
+			t.Fatalf("got %s, want %s", decoded, m)
+		}
+	})
+}
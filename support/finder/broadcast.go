@@ -56,6 +56,47 @@ func ParseBroadcast(data []byte) (*Broadcast, error) {
 	}, nil
 }
 
+// NewBroadcast returns a new Broadcast instance with the given data. This is
+// mostly useful for emulating robots (see Emulator).
+func NewBroadcast(isPairing bool, sourceIp net.IP, sourceMac net.HardwareAddr,
+	appId uint64) (*Broadcast, error) {
+	ip := sourceIp.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("not an IPv4 address: %s", sourceIp)
+	}
+
+	if len(sourceMac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address: %s", sourceMac)
+	}
+
+	return &Broadcast{
+		isPairing,
+		append(net.IP(nil), ip...),
+		append(net.HardwareAddr(nil), sourceMac...),
+		appId,
+	}, nil
+}
+
+// Bytes returns the encoded (encrypted) broadcast message, as sent by a robot.
+// It is the inverse of ParseBroadcast.
+func (b *Broadcast) Bytes() []byte {
+	data := make([]byte, broadcastLen)
+
+	copy(data, broadcastHeader)
+
+	if b.isPairing {
+		data[2] = 1
+	}
+
+	copy(data[6:10], b.sourceIp.To4())
+	copy(data[10:16], b.sourceMac)
+	binary.LittleEndian.PutUint64(data[16:], b.appId)
+
+	support.SimpleEncryptDecrypt(data)
+
+	return data
+}
+
 func (b *Broadcast) IsPairing() bool {
 	return b.isPairing
 }
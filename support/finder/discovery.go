@@ -0,0 +1,381 @@
+package finder
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+)
+
+// DefaultDiscoveryTimeout is the default time without broadcasts after which a
+// robot is considered gone. Robots broadcast around once per second.
+const DefaultDiscoveryTimeout = 5 * time.Second
+
+// RobotInfo is the information about a robot detected in the network.
+type RobotInfo struct {
+	IP        net.IP
+	MAC       net.HardwareAddr
+	AppID     uint64
+	IsPairing bool
+
+	// FirstSeen is the time the first broadcast from the robot was received.
+	FirstSeen time.Time
+
+	// LastSeen is the time the last broadcast from the robot was received.
+	LastSeen time.Time
+}
+
+// String returns a string representation of the robot info.
+func (ri RobotInfo) String() string {
+	return fmt.Sprintf("IP:%s, MAC:%s, AppID:%d, IsPairing:%t", ri.IP, ri.MAC,
+		ri.AppID, ri.IsPairing)
+}
+
+// DiscoveryEventType is the type of a discovery event.
+type DiscoveryEventType uint8
+
+const (
+	// DiscoveryEventTypeAppeared is sent when a robot is first detected (or
+	// detected again after disappearing).
+	DiscoveryEventTypeAppeared DiscoveryEventType = iota
+
+	// DiscoveryEventTypeChanged is sent when the IP, appID or pairing state
+	// of an already detected robot changes.
+	DiscoveryEventTypeChanged
+
+	// DiscoveryEventTypeDisappeared is sent when no broadcasts were received
+	// from a robot for the discovery timeout.
+	DiscoveryEventTypeDisappeared
+
+	DiscoveryEventTypeCount
+)
+
+// String returns the discovery event type as a string.
+func (det DiscoveryEventType) String() string {
+	switch det {
+	case DiscoveryEventTypeAppeared:
+		return "Appeared"
+	case DiscoveryEventTypeChanged:
+		return "Changed"
+	case DiscoveryEventTypeDisappeared:
+		return "Disappeared"
+	default:
+		return "Invalid"
+	}
+}
+
+// Valid returns true if the discovery event type is valid.
+func (det DiscoveryEventType) Valid() bool {
+	return det < DiscoveryEventTypeCount
+}
+
+// DiscoveryEvent is an event about a robot in the network.
+type DiscoveryEvent struct {
+	Type  DiscoveryEventType
+	Robot RobotInfo
+}
+
+// Discovery continuously tracks all robots broadcasting in the network.
+type Discovery struct {
+	l *logger.Logger
+
+	m             sync.Mutex
+	listeningConn *net.UDPConn
+	quit          chan struct{}
+	done          chan struct{}
+	rt            *robotTracker
+	err           error
+}
+
+// NewDiscovery returns a new Discovery instance. If no appIDs are given,
+// robots are reported regardless of their appID. Otherwise only robots with one
+// of the given appIDs are reported. If timeout is zero, DefaultDiscoveryTimeout
+// is used.
+func NewDiscovery(appIDs []uint64, timeout time.Duration,
+	l *logger.Logger) *Discovery {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("discovery")
+
+	if timeout <= 0 {
+		timeout = DefaultDiscoveryTimeout
+	}
+
+	return &Discovery{
+		l:  l,
+		rt: newRobotTracker(appIDs, timeout),
+	}
+}
+
+// Start starts tracking robots in the network. Events are sent to the given
+// channel, which must be drained by the caller. It returns a non-nil error if
+// it is already started. If tracking stops due to a network error, the
+// Discovery is stopped and the error is available through Err.
+func (d *Discovery) Start(ch chan<- DiscoveryEvent) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.quit != nil {
+		return fmt.Errorf("already started")
+	}
+
+	listeningConn, err := listener(ipBroadcastAddrPort)
+	if err != nil {
+		return err
+	}
+
+	d.listeningConn = listeningConn
+	d.quit = make(chan struct{})
+	d.done = make(chan struct{})
+	d.err = nil
+
+	go d.loop(ch, d.listeningConn, d.quit, d.done)
+
+	return nil
+}
+
+// Stop stops tracking robots in the network and waits for the tracking
+// goroutine to exit. The list of known robots is cleared without generating
+// events. It returns a non-nil error if it is not started.
+func (d *Discovery) Stop() error {
+	d.m.Lock()
+
+	if d.quit == nil {
+		d.m.Unlock()
+		return fmt.Errorf("not started")
+	}
+
+	close(d.quit)
+	d.listeningConn.Close()
+
+	done := d.done
+
+	d.quit = nil
+	d.done = nil
+	d.listeningConn = nil
+
+	d.m.Unlock()
+
+	<-done
+
+	d.m.Lock()
+	d.rt.reset()
+	d.m.Unlock()
+
+	return nil
+}
+
+// Err returns the error that stopped tracking robots (for example, if the
+// network interface went away), or nil if it did not stop due to an error. It
+// is reset by Start.
+func (d *Discovery) Err() error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.err
+}
+
+// Robots returns all robots currently detected in the network, sorted by IP.
+func (d *Discovery) Robots() []RobotInfo {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.rt.robots()
+}
+
+func (d *Discovery) loop(ch chan<- DiscoveryEvent, conn *net.UDPConn,
+	quit <-chan struct{}, done chan<- struct{}) {
+	d.l.Debug("Starting robot discovery.")
+	defer d.l.Debug("Stopped robot discovery.")
+
+	defer close(done)
+
+	buf := make([]byte, 1024)
+
+	for {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		var events []DiscoveryEvent
+
+		conn.SetReadDeadline(time.Now().Add(1 * time.Second))
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err == nil {
+			broadcast, err := parseAndValidateBroadcast(buf[:n], addr)
+			if err != nil {
+				d.l.Warn("Error parsing broadcast message.", "error", err)
+			} else {
+				d.m.Lock()
+				e, ok := d.rt.update(broadcast, time.Now())
+				d.m.Unlock()
+
+				if ok {
+					events = append(events, e)
+				}
+			}
+		} else if opErr, ok := err.(*net.OpError); !ok || !opErr.Timeout() {
+			select {
+			case <-quit:
+				// Connection closed by Stop().
+			default:
+				d.l.Error("Error receiving broadcast. Discovery stopped.",
+					"error", err)
+				d.fail(quit, err)
+			}
+
+			return
+		}
+
+		d.m.Lock()
+		events = append(events, d.rt.expire(time.Now())...)
+		d.m.Unlock()
+
+		for _, e := range events {
+			d.l.Debug("Discovery event.", "type", e.Type, "robot", e.Robot)
+
+			select {
+			case ch <- e:
+			case <-quit:
+				return
+			}
+		}
+	}
+}
+
+// fail clears the started state after the loop associated with the given quit
+// channel stopped due to the given error.
+func (d *Discovery) fail(quit <-chan struct{}, err error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.quit != quit {
+		// Concurrently stopped.
+		return
+	}
+
+	d.listeningConn.Close()
+
+	d.quit = nil
+	d.done = nil
+	d.listeningConn = nil
+	d.err = err
+
+	d.rt.reset()
+}
+
+// robotTracker keeps track of robots seen in the network. It is not thread
+// safe.
+type robotTracker struct {
+	appIDs  map[uint64]struct{}
+	timeout time.Duration
+
+	// Keyed by MAC address as the IP might change.
+	known map[string]*RobotInfo
+}
+
+func newRobotTracker(appIDs []uint64, timeout time.Duration) *robotTracker {
+	rt := &robotTracker{
+		timeout: timeout,
+		known:   make(map[string]*RobotInfo),
+	}
+
+	if len(appIDs) > 0 {
+		rt.appIDs = make(map[uint64]struct{}, len(appIDs))
+		for _, appID := range appIDs {
+			rt.appIDs[appID] = struct{}{}
+		}
+	}
+
+	return rt
+}
+
+// update updates the tracked robots with the given broadcast and returns an
+// event if anything relevant changed.
+func (rt *robotTracker) update(b *Broadcast, now time.Time) (DiscoveryEvent,
+	bool) {
+	if rt.appIDs != nil {
+		if _, ok := rt.appIDs[b.AppId()]; !ok {
+			return DiscoveryEvent{}, false
+		}
+	}
+
+	mac := b.SourceMac().String()
+
+	ri, ok := rt.known[mac]
+	if !ok {
+		ri = &RobotInfo{
+			IP:        copyBytes(b.SourceIp()),
+			MAC:       copyBytes(b.SourceMac()),
+			AppID:     b.AppId(),
+			IsPairing: b.IsPairing(),
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+
+		rt.known[mac] = ri
+
+		return DiscoveryEvent{DiscoveryEventTypeAppeared, *ri}, true
+	}
+
+	ri.LastSeen = now
+
+	if ri.IP.Equal(b.SourceIp()) && ri.AppID == b.AppId() &&
+		ri.IsPairing == b.IsPairing() {
+		return DiscoveryEvent{}, false
+	}
+
+	ri.IP = copyBytes(b.SourceIp())
+	ri.AppID = b.AppId()
+	ri.IsPairing = b.IsPairing()
+
+	return DiscoveryEvent{DiscoveryEventTypeChanged, *ri}, true
+}
+
+// expire removes robots that were not seen for the timeout and returns the
+// associated events.
+func (rt *robotTracker) expire(now time.Time) []DiscoveryEvent {
+	var events []DiscoveryEvent
+
+	for mac, ri := range rt.known {
+		if now.Sub(ri.LastSeen) < rt.timeout {
+			continue
+		}
+
+		delete(rt.known, mac)
+
+		events = append(events, DiscoveryEvent{DiscoveryEventTypeDisappeared,
+			*ri})
+	}
+
+	return events
+}
+
+func (rt *robotTracker) robots() []RobotInfo {
+	robots := make([]RobotInfo, 0, len(rt.known))
+	for _, ri := range rt.known {
+		robots = append(robots, *ri)
+	}
+
+	sort.Slice(robots, func(i, j int) bool {
+		return robots[i].IP.String() < robots[j].IP.String()
+	})
+
+	return robots
+}
+
+func (rt *robotTracker) reset() {
+	rt.known = make(map[string]*RobotInfo)
+}
+
+func copyBytes[T ~[]byte](b T) T {
+	return append(T(nil), b...)
+}
@@ -0,0 +1,117 @@
+package finder
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func testBroadcast(ip string, mac byte, appID uint64,
+	isPairing bool) *Broadcast {
+	return &Broadcast{
+		isPairing: isPairing,
+		sourceIp:  net.ParseIP(ip).To4(),
+		sourceMac: net.HardwareAddr{0, 1, 2, 3, 4, mac},
+		appId:     appID,
+	}
+}
+
+func TestRobotTrackerEvents(t *testing.T) {
+	rt := newRobotTracker(nil, 5*time.Second)
+
+	start := time.Now()
+
+	tests := []struct {
+		b      *Broadcast
+		offset time.Duration
+		want   DiscoveryEventType
+		wantOk bool
+	}{
+		{testBroadcast("192.168.2.1", 1, 1, true), 0, DiscoveryEventTypeAppeared, true},
+		{testBroadcast("192.168.2.1", 1, 1, true), time.Second, 0, false},
+		{testBroadcast("192.168.2.1", 1, 1, false), 2 * time.Second, DiscoveryEventTypeChanged, true},
+		{testBroadcast("192.168.2.2", 2, 2, false), 2 * time.Second, DiscoveryEventTypeAppeared, true},
+		{testBroadcast("192.168.2.3", 1, 1, false), 3 * time.Second, DiscoveryEventTypeChanged, true},
+	}
+
+	for i, test := range tests {
+		e, ok := rt.update(test.b, start.Add(test.offset))
+		if ok != test.wantOk {
+			t.Fatalf("step %d: got event %t, want %t", i, ok, test.wantOk)
+		}
+
+		if ok && e.Type != test.want {
+			t.Errorf("step %d: got event type %s, want %s", i, e.Type,
+				test.want)
+		}
+	}
+
+	robots := rt.robots()
+	if len(robots) != 2 {
+		t.Fatalf("got %d robots, want 2", len(robots))
+	}
+
+	if !robots[1].IP.Equal(net.ParseIP("192.168.2.3")) ||
+		!robots[1].FirstSeen.Equal(start) ||
+		!robots[1].LastSeen.Equal(start.Add(3*time.Second)) {
+		t.Errorf("unexpected robot info: %+v", robots[1])
+	}
+
+	events := rt.expire(start.Add(7 * time.Second))
+	if len(events) != 1 || events[0].Type != DiscoveryEventTypeDisappeared ||
+		events[0].Robot.AppID != 2 {
+		t.Errorf("got events %v, want robot with appID 2 disappeared", events)
+	}
+
+	if len(rt.robots()) != 1 {
+		t.Errorf("got %d robots, want 1", len(rt.robots()))
+	}
+}
+
+func TestRobotTrackerAppIDFilter(t *testing.T) {
+	rt := newRobotTracker([]uint64{1, 3}, DefaultDiscoveryTimeout)
+
+	now := time.Now()
+
+	for appID := uint64(1); appID <= 3; appID++ {
+		_, ok := rt.update(testBroadcast("192.168.2.1", byte(appID), appID,
+			false), now)
+		if ok != (appID != 2) {
+			t.Errorf("appID %d: got event %t", appID, ok)
+		}
+	}
+}
+
+func TestDiscoveryReadError(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDiscovery(nil, 0, nil)
+
+	d.listeningConn = conn
+	d.quit = make(chan struct{})
+	d.done = make(chan struct{})
+
+	done := d.done
+
+	// Reading from a closed connection fails with a non-timeout error.
+	conn.Close()
+
+	go d.loop(make(chan DiscoveryEvent), conn, d.quit, d.done)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("discovery loop did not exit")
+	}
+
+	if d.Err() == nil {
+		t.Error("expected error")
+	}
+
+	if d.Stop() == nil {
+		t.Error("expected error stopping discovery that stopped on its own")
+	}
+}
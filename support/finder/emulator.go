@@ -0,0 +1,354 @@
+package finder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+)
+
+// EmulatedRobot describes a fake robot broadcasting in the network.
+type EmulatedRobot struct {
+	// IP is the robot IP. Broadcasts are sent from this IP and ACKs are
+	// received on it, so it must be assignable to the host (for example,
+	// any 127.0.0.0/8 address on Linux).
+	IP net.IP
+
+	MAC       net.HardwareAddr
+	AppID     uint64
+	IsPairing bool
+}
+
+// EmulatorOptions configures an Emulator.
+type EmulatorOptions struct {
+	// Target is the address broadcasts are sent to. Use "127.0.0.1:45678" to
+	// test on localhost.
+	Target string
+
+	// Interval is the interval between broadcasts.
+	Interval time.Duration
+
+	// OnACK, if not nil, is called (in a separate goroutine) whenever an
+	// emulated robot receives an ACK. The robot is passed with its state after
+	// the ACK was applied.
+	OnACK func(r EmulatedRobot, appID uint64)
+}
+
+// DefaultEmulatorOptions are the default emulator options, matching real
+// robots.
+var DefaultEmulatorOptions = EmulatorOptions{
+	Target:   "255.255.255.255" + ipBroadcastAddrPort,
+	Interval: 1 * time.Second,
+}
+
+// Emulator emulates robots broadcasting their IPs in the network so discovery
+// and pairing logic can be tested without real robots. Just like real robots,
+// an emulated robot in pairing mode that receives an ACK adopts the ACKed
+// appID and leaves pairing mode.
+type Emulator struct {
+	opts EmulatorOptions
+	l    *logger.Logger
+
+	m       sync.Mutex
+	started bool
+	robots  map[string]*emulatedRobot
+}
+
+// NewEmulator returns a new Emulator instance.
+func NewEmulator(opts EmulatorOptions, l *logger.Logger) (*Emulator, error) {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("emulator")
+
+	if opts.Target == "" {
+		opts.Target = DefaultEmulatorOptions.Target
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultEmulatorOptions.Interval
+	}
+
+	_, err := net.ResolveUDPAddr("udp4", opts.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+
+	return &Emulator{
+		opts:   opts,
+		l:      l,
+		robots: make(map[string]*emulatedRobot),
+	}, nil
+}
+
+// AddRobot adds a robot to the emulator. If the emulator is started, the robot
+// starts broadcasting immediately.
+func (e *Emulator) AddRobot(r EmulatedRobot) error {
+	b, err := NewBroadcast(r.IsPairing, r.IP, r.MAC, r.AppID)
+	if err != nil {
+		return err
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	mac := b.SourceMac().String()
+
+	if _, ok := e.robots[mac]; ok {
+		return fmt.Errorf("robot with MAC %s already added", mac)
+	}
+
+	er := &emulatedRobot{
+		e: e,
+		r: EmulatedRobot{
+			IP:        b.SourceIp(),
+			MAC:       b.SourceMac(),
+			AppID:     r.AppID,
+			IsPairing: r.IsPairing,
+		},
+	}
+
+	if e.started {
+		err = er.start()
+		if err != nil {
+			return err
+		}
+	}
+
+	e.robots[mac] = er
+
+	return nil
+}
+
+// RemoveRobot removes the robot with the given MAC address from the emulator.
+// It stops broadcasting immediately.
+func (e *Emulator) RemoveRobot(mac net.HardwareAddr) error {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	er, ok := e.robots[mac.String()]
+	if !ok {
+		return fmt.Errorf("no robot with MAC %s", mac)
+	}
+
+	if e.started {
+		er.stop()
+	}
+
+	delete(e.robots, mac.String())
+
+	return nil
+}
+
+// SetPairing sets the pairing state of the robot with the given MAC address.
+func (e *Emulator) SetPairing(mac net.HardwareAddr, isPairing bool) error {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	er, ok := e.robots[mac.String()]
+	if !ok {
+		return fmt.Errorf("no robot with MAC %s", mac)
+	}
+
+	er.m.Lock()
+	er.r.IsPairing = isPairing
+	er.m.Unlock()
+
+	return nil
+}
+
+// Robots returns the current state of all emulated robots, sorted by IP.
+func (e *Emulator) Robots() []EmulatedRobot {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	robots := make([]EmulatedRobot, 0, len(e.robots))
+	for _, er := range e.robots {
+		robots = append(robots, er.robot())
+	}
+
+	sort.Slice(robots, func(i, j int) bool {
+		return robots[i].IP.String() < robots[j].IP.String()
+	})
+
+	return robots
+}
+
+// Start starts broadcasting for all added robots. It returns a non-nil error if
+// it is already started.
+func (e *Emulator) Start() error {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if e.started {
+		return fmt.Errorf("already started")
+	}
+
+	var started []*emulatedRobot
+	for _, er := range e.robots {
+		err := er.start()
+		if err != nil {
+			for _, s := range started {
+				s.stop()
+			}
+
+			return err
+		}
+
+		started = append(started, er)
+	}
+
+	e.started = true
+
+	return nil
+}
+
+// Stop stops broadcasting for all robots. It returns a non-nil error if it is
+// not started.
+func (e *Emulator) Stop() error {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if !e.started {
+		return fmt.Errorf("not started")
+	}
+
+	for _, er := range e.robots {
+		er.stop()
+	}
+
+	e.started = false
+
+	return nil
+}
+
+type emulatedRobot struct {
+	e *Emulator
+
+	m sync.Mutex
+	r EmulatedRobot
+
+	sendConn *net.UDPConn
+	ackConn  *net.UDPConn
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func (er *emulatedRobot) robot() EmulatedRobot {
+	er.m.Lock()
+	defer er.m.Unlock()
+
+	return er.r
+}
+
+func (er *emulatedRobot) start() error {
+	target, err := net.ResolveUDPAddr("udp4", er.e.opts.Target)
+	if err != nil {
+		return err
+	}
+
+	sendConn, err := net.DialUDP("udp4", &net.UDPAddr{IP: er.r.IP}, target)
+	if err != nil {
+		return fmt.Errorf("error creating broadcast connection for %s: %w",
+			er.r.IP, err)
+	}
+
+	ackAddr, err := net.ResolveUDPAddr("udp4",
+		er.r.IP.String()+listenerRemotePort)
+	if err != nil {
+		sendConn.Close()
+		return err
+	}
+
+	ackConn, err := net.ListenUDP("udp4", ackAddr)
+	if err != nil {
+		sendConn.Close()
+		return fmt.Errorf("error creating ACK listener for %s: %w", er.r.IP,
+			err)
+	}
+
+	er.sendConn = sendConn
+	er.ackConn = ackConn
+	er.quit = make(chan struct{})
+
+	er.wg.Add(2)
+	go er.broadcastLoop()
+	go er.ackLoop()
+
+	return nil
+}
+
+func (er *emulatedRobot) stop() {
+	close(er.quit)
+	er.sendConn.Close()
+	er.ackConn.Close()
+
+	er.wg.Wait()
+}
+
+func (er *emulatedRobot) broadcastLoop() {
+	defer er.wg.Done()
+
+	ticker := time.NewTicker(er.e.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		r := er.robot()
+
+		// Robot was validated when added, so this can not fail.
+		b, _ := NewBroadcast(r.IsPairing, r.IP, r.MAC, r.AppID)
+
+		_, err := er.sendConn.Write(b.Bytes())
+		if err != nil {
+			er.e.l.Warn("Error sending broadcast.", "robot", r.IP, "error",
+				err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-er.quit:
+			return
+		}
+	}
+}
+
+func (er *emulatedRobot) ackLoop() {
+	defer er.wg.Done()
+
+	buf := make([]byte, 1024)
+
+	for {
+		n, _, err := er.ackConn.ReadFromUDP(buf)
+		if err != nil {
+			// Connection closed.
+			return
+		}
+
+		if n != 8 {
+			er.e.l.Warn("Unexpected ACK length.", "length", n)
+			continue
+		}
+
+		appID := binary.LittleEndian.Uint64(buf[:n])
+
+		er.m.Lock()
+		if er.r.IsPairing {
+			er.r.AppID = appID
+			er.r.IsPairing = false
+		}
+		r := er.r
+		er.m.Unlock()
+
+		er.e.l.Debug("Received ACK.", "robot", r.IP, "appID", appID)
+
+		if er.e.opts.OnACK != nil {
+			go er.e.opts.OnACK(r, appID)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package finder
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBroadcastBytesRoundTrip(t *testing.T) {
+	b, err := NewBroadcast(true, net.ParseIP("192.168.2.1"),
+		net.HardwareAddr{1, 2, 3, 4, 5, 6}, 0x0123456789abcdef)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseBroadcast(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.IsPairing() != b.IsPairing() ||
+		!parsed.SourceIp().Equal(b.SourceIp()) ||
+		!bytes.Equal(parsed.SourceMac(), b.SourceMac()) ||
+		parsed.AppId() != b.AppId() {
+		t.Errorf("got %s, want %s", parsed, b)
+	}
+}
+
+func TestNewBroadcastInvalid(t *testing.T) {
+	_, err := NewBroadcast(false, net.ParseIP("::1"),
+		net.HardwareAddr{1, 2, 3, 4, 5, 6}, 1)
+	if err == nil {
+		t.Error("expected error for IPv6 address")
+	}
+
+	_, err = NewBroadcast(false, net.ParseIP("192.168.2.1"),
+		net.HardwareAddr{1, 2, 3}, 1)
+	if err == nil {
+		t.Error("expected error for invalid MAC address")
+	}
+}
+
+func TestEmulatorDiscoveryAndPairing(t *testing.T) {
+	acks := make(chan EmulatedRobot, 1)
+
+	e, err := NewEmulator(EmulatorOptions{
+		Target:   "127.0.0.1" + ipBroadcastAddrPort,
+		Interval: 100 * time.Millisecond,
+		OnACK: func(r EmulatedRobot, appID uint64) {
+			acks <- r
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mac := net.HardwareAddr{1, 2, 3, 4, 5, 6}
+
+	err = e.AddRobot(EmulatedRobot{
+		IP:        net.ParseIP("127.0.0.2"),
+		MAC:       mac,
+		IsPairing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDiscovery(nil, 500*time.Millisecond, nil)
+
+	ch := make(chan DiscoveryEvent, 10)
+
+	err = d.Start(ch)
+	if err != nil {
+		t.Skipf("can not listen for broadcasts: %s", err)
+	}
+	defer d.Stop()
+
+	err = e.Start()
+	if err != nil {
+		t.Skipf("can not start emulator: %s", err)
+	}
+
+	ev := waitForEvent(t, ch)
+	if ev.Type != DiscoveryEventTypeAppeared || !ev.Robot.IsPairing {
+		t.Fatalf("got event %s (%s), want pairing robot appeared", ev.Type,
+			ev.Robot)
+	}
+
+	New(0, nil).SendACK(ev.Robot.IP, 1234)
+
+	select {
+	case r := <-acks:
+		if r.AppID != 1234 || r.IsPairing {
+			t.Errorf("got robot %+v after ACK", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for ACK")
+	}
+
+	ev = waitForEvent(t, ch)
+	if ev.Type != DiscoveryEventTypeChanged || ev.Robot.AppID != 1234 ||
+		ev.Robot.IsPairing {
+		t.Fatalf("got event %s (%s), want paired robot changed", ev.Type,
+			ev.Robot)
+	}
+
+	err = e.RemoveRobot(mac)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev = waitForEvent(t, ch)
+	if ev.Type != DiscoveryEventTypeDisappeared {
+		t.Fatalf("got event %s, want disappeared", ev.Type)
+	}
+
+	err = e.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitForEvent(t *testing.T, ch <-chan DiscoveryEvent) DiscoveryEvent {
+	t.Helper()
+
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for discovery event")
+	}
+
+	return DiscoveryEvent{}
+}
@@ -0,0 +1,82 @@
+// Package history provides a bounded history of time ordered samples.
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// History is a bounded ring buffer of samples ordered by time. When full,
+// adding a new sample evicts the oldest one. It is not thread safe.
+type History[T any] struct {
+	samples []T
+	next    int
+	full    bool
+
+	timeOf func(T) time.Time
+}
+
+// New creates a new History that holds up to size samples. The timeOf
+// function must return the time associated with a sample.
+func New[T any](size int, timeOf func(T) time.Time) *History[T] {
+	return &History[T]{
+		samples: make([]T, size),
+		timeOf:  timeOf,
+	}
+}
+
+// Add adds the given sample to the history, evicting the oldest one if the
+// history is full. Samples older than the newest one are dropped. Returns true
+// if the sample was added.
+func (h *History[T]) Add(s T) bool {
+	if n := h.Len(); n > 0 && h.timeOf(s).Before(h.timeOf(h.At(n-1))) {
+		return false
+	}
+
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	return true
+}
+
+// Len returns the number of samples in the history.
+func (h *History[T]) Len() int {
+	if h.full {
+		return len(h.samples)
+	}
+
+	return h.next
+}
+
+// At returns the i-th oldest sample.
+func (h *History[T]) At(i int) T {
+	if !h.full {
+		return h.samples[i]
+	}
+
+	return h.samples[(h.next+i)%len(h.samples)]
+}
+
+// Search returns the index of the oldest sample at or after the given time.
+// Returns Len() if there is no such sample.
+func (h *History[T]) Search(t time.Time) int {
+	return sort.Search(h.Len(), func(i int) bool {
+		return !h.timeOf(h.At(i)).Before(t)
+	})
+}
+
+// Since returns all samples at or after the given time, oldest first.
+func (h *History[T]) Since(t time.Time) []T {
+	n := h.Len()
+	i := h.Search(t)
+
+	samples := make([]T, 0, n-i)
+	for ; i < n; i++ {
+		samples = append(samples, h.At(i))
+	}
+
+	return samples
+}
@@ -0,0 +1,60 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+type sample struct {
+	v int
+	t time.Time
+}
+
+func newTestHistory(size int) *History[sample] {
+	return New(size, func(s sample) time.Time {
+		return s.t
+	})
+}
+
+func TestHistoryWraparound(t *testing.T) {
+	h := newTestHistory(3)
+
+	start := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		if !h.Add(sample{i, start.Add(time.Duration(i) * time.Second)}) {
+			t.Fatalf("sample %d not added", i)
+		}
+	}
+
+	if h.Len() != 3 {
+		t.Fatalf("got %d samples, want 3", h.Len())
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := h.At(i).v; got != i+2 {
+			t.Errorf("sample %d: got %d, want %d", i, got, i+2)
+		}
+	}
+
+	// Out of order.
+	if h.Add(sample{5, start}) {
+		t.Error("out of order sample added")
+	}
+
+	since := h.Since(start.Add(3 * time.Second))
+	if len(since) != 2 || since[0].v != 3 || since[1].v != 4 {
+		t.Errorf("unexpected samples since: %v", since)
+	}
+
+	if i := h.Search(start.Add(10 * time.Second)); i != h.Len() {
+		t.Errorf("got index %d, want %d", i, h.Len())
+	}
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	h := newTestHistory(3)
+
+	if h.Len() != 0 || len(h.Since(time.Time{})) != 0 {
+		t.Errorf("expected empty history")
+	}
+}
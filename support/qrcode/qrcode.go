@@ -13,6 +13,18 @@ import (
 	"github.com/skip2/go-qrcode"
 )
 
+// Maximum lengths that fit in the message metadata. The metadata layout is the
+// one this package always used to encode messages (see encodeMessage): SSID
+// length in bits 0-5, password length starting at bit 6 and the BSSID flag in
+// bit 11. That leaves 5 bits for the password length, so passwords longer than
+// 31 bytes (WPA2 allows up to 63) would overwrite the BSSID flag and are
+// rejected. Whether the robot supports longer passwords through some other
+// encoding is not known.
+const (
+	maxSsIDLen     = 0b111111
+	maxPasswordLen = 0b11111
+)
+
 // QRCode handles generating and parsing the data for the Robomaster connection
 // qrcode that is used to associate the robot with an app ID and also to tell
 // it about the network and password to use.
@@ -36,11 +48,18 @@ func New(appID uint64,
 	if len(trimmedSsID) == 0 {
 		return nil, fmt.Errorf("SSID must be non-empty")
 	}
+	if len(trimmedSsID) > maxSsIDLen {
+		return nil, fmt.Errorf("SSID must have at most %d bytes", maxSsIDLen)
+	}
 
 	trimmedPassword := strings.TrimSpace(password)
 	if len(trimmedPassword) == 0 {
 		return nil, fmt.Errorf("password must be non-empty")
 	}
+	if len(trimmedPassword) > maxPasswordLen {
+		return nil, fmt.Errorf("password must have at most %d bytes",
+			maxPasswordLen)
+	}
 
 	var resultBssID *net.HardwareAddr
 	if len(strings.TrimSpace(bssID)) != 0 {
@@ -151,6 +170,45 @@ func (q *QRCode) Text() (string, error) {
 	return sb.String(), nil
 }
 
+// CompactText is like Text but uses Unicode half blocks to render two module
+// rows per line, resulting in an output 4 times smaller that fits most
+// terminals (including over SSH). As with Text, it assumes light text on a
+// dark background.
+func (q *QRCode) CompactText() (string, error) {
+	qrc, err := qrcode.New(q.encodeMessage(), qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+
+	return compactText(qrc.Bitmap()), nil
+}
+
+func compactText(bitmap [][]bool) string {
+	var sb strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		for x := range bitmap[y] {
+			// Rows past the end are considered part of the (white) quiet
+			// zone.
+			top := !bitmap[y][x]
+			bottom := y+1 >= len(bitmap) || !bitmap[y+1][x]
+
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
 func (q *QRCode) encodeMessage() string {
 	var b bytes.Buffer
 
@@ -197,13 +255,24 @@ func (q *QRCode) decodeMessage(message string) error {
 		return err
 	}
 
+	// Metadata (2) + app ID (8) + country code (2).
+	if len(data) < 12 {
+		return fmt.Errorf("message too short: %d bytes", len(data))
+	}
+
 	support.SimpleEncryptDecrypt(data)
 
 	metadata := binary.LittleEndian.Uint16(data)
 
-	hasBssId := metadata >> 11
-	lenPassword := (metadata & 0b0000001111111111) >> 6
-	lenSsId := (metadata & 0b0000000001111111)
+	hasBssId := (metadata >> 11) & 1
+	lenPassword := (metadata & 0b0000011111000000) >> 6
+	lenSsId := (metadata & 0b0000000000111111)
+
+	expectedLen := 12 + int(lenSsId) + int(lenPassword) + 12*int(hasBssId)
+	if len(data) != expectedLen {
+		return fmt.Errorf("unexpected message length: got %d bytes, want %d",
+			len(data), expectedLen)
+	}
 
 	q.appID = binary.LittleEndian.Uint64(data[2:])
 
@@ -0,0 +1,126 @@
+package qrcode
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	for _, bssID := range []string{"", "01:02:03:04:05:06"} {
+		q, err := New(1234, "US", "network", "password", bssID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := NewFromMessage(q.Message())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed.String() != q.String() {
+			t.Errorf("got %s, want %s", parsed, q)
+		}
+	}
+}
+
+func TestMessageRoundTripMaxLengths(t *testing.T) {
+	q, err := New(1, "BR", strings.Repeat("s", maxSsIDLen),
+		strings.Repeat("p", maxPasswordLen), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := NewFromMessage(q.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.SsID() != q.SsID() || parsed.Password() != q.Password() {
+		t.Errorf("got %s, want %s", parsed, q)
+	}
+
+	_, err = New(1, "BR", strings.Repeat("s", maxSsIDLen+1), "p", "")
+	if err == nil {
+		t.Error("expected error for SSID too long")
+	}
+
+	_, err = New(1, "BR", "s", strings.Repeat("p", maxPasswordLen+1), "")
+	if err == nil {
+		t.Error("expected error for password too long")
+	}
+}
+
+func TestNewFromMessageMalformed(t *testing.T) {
+	q, err := New(1234, "US", "network", "password", "01:02:03:04:05:06")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(q.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(data); i++ {
+		_, err := NewFromMessage(base64.StdEncoding.EncodeToString(data[:i]))
+		if err == nil {
+			t.Errorf("expected error for message truncated to %d bytes", i)
+		}
+	}
+
+	_, err = NewFromMessage("not base64!")
+	if err == nil {
+		t.Error("expected error for invalid base64")
+	}
+}
+
+func FuzzNewFromMessage(f *testing.F) {
+	q, err := New(1234, "US", "network", "password", "01:02:03:04:05:06")
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	data, _ := base64.StdEncoding.DecodeString(q.Message())
+	f.Add(data)
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Must not panic.
+		NewFromMessage(base64.StdEncoding.EncodeToString(data))
+	})
+}
+
+func TestCompactText(t *testing.T) {
+	bitmap := [][]bool{
+		{false, false, true, true},
+		{false, true, false, true},
+		{true, false, false, true},
+	}
+
+	want := "█▀▄ \n▄██▄\n"
+
+	if got := compactText(bitmap); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	q, err := New(1234, "US", "network", "password", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := q.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compact, err := q.CompactText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := strings.Count(text, "\n")
+	if got := strings.Count(compact, "\n"); got != (rows+1)/2 {
+		t.Errorf("got %d lines, want %d", got, (rows+1)/2)
+	}
+}
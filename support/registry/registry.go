@@ -0,0 +1,360 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support"
+	"github.com/brunoga/robomaster/support/finder"
+	"github.com/brunoga/robomaster/support/qrcode"
+)
+
+// ErrNotFound is returned when there is no robot with the given name in the
+// registry.
+var ErrNotFound = errors.New("robot not found")
+
+// WiFi is the Wi-Fi configuration sent to a robot through the pairing QR code.
+type WiFi struct {
+	CountryCode string `json:"country_code"`
+	SSID        string `json:"ssid"`
+	Password    string `json:"password"`
+	BSSID       string `json:"bssid,omitempty"`
+}
+
+// Robot is a robot paired with this host.
+type Robot struct {
+	Name  string `json:"name"`
+	AppID uint64 `json:"app_id"`
+	WiFi  WiFi   `json:"wifi"`
+	Notes string `json:"notes,omitempty"`
+
+	// Last known robot network information. Updated through Observe.
+	LastIP   string    `json:"last_ip,omitempty"`
+	LastMAC  string    `json:"last_mac,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// QRCode returns the QR code that pairs the robot with its appID and Wi-Fi
+// configuration.
+func (r Robot) QRCode() (*qrcode.QRCode, error) {
+	return qrcode.New(r.AppID, r.WiFi.CountryCode, r.WiFi.SSID,
+		r.WiFi.Password, r.WiFi.BSSID)
+}
+
+// Registry is a persistent list of paired robots, stored as a JSON file. Note
+// the file includes the Wi-Fi passwords so it is only readable by the current
+// user. All changes are saved immediately.
+type Registry struct {
+	path string
+
+	m      sync.Mutex
+	robots map[string]*Robot
+}
+
+// DefaultPath returns the default registry path (robomaster/robots.json in the
+// user config dir).
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "robomaster", "robots.json"), nil
+}
+
+// Open opens the registry at the given path. If path is empty, DefaultPath is
+// used. A non-existing file results in an empty registry.
+func Open(path string) (*Registry, error) {
+	if path == "" {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Registry{
+		path:   path,
+		robots: make(map[string]*Robot),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return r, nil
+		}
+
+		return nil, err
+	}
+
+	var robots []*Robot
+	err = json.Unmarshal(data, &robots)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing registry %q: %w", path, err)
+	}
+
+	for _, robot := range robots {
+		r.robots[robot.Name] = robot
+	}
+
+	return r, nil
+}
+
+// Path returns the registry file path.
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// Robots returns all robots in the registry, sorted by name.
+func (r *Registry) Robots() []Robot {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	robots := make([]Robot, 0, len(r.robots))
+	for _, robot := range r.robots {
+		robots = append(robots, *robot)
+	}
+
+	sort.Slice(robots, func(i, j int) bool {
+		return robots[i].Name < robots[j].Name
+	})
+
+	return robots
+}
+
+// Get returns the robot with the given name.
+func (r *Registry) Get(name string) (Robot, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	robot, ok := r.robots[name]
+	if !ok {
+		return Robot{}, fmt.Errorf("%q: %w", name, ErrNotFound)
+	}
+
+	return *robot, nil
+}
+
+// Add adds the given robot to the registry. The name and appID must be unique.
+func (r *Registry) Add(robot Robot) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	err := r.validateLocked(robot, "")
+	if err != nil {
+		return err
+	}
+
+	r.robots[robot.Name] = &robot
+
+	err = r.saveLocked()
+	if err != nil {
+		delete(r.robots, robot.Name)
+		return err
+	}
+
+	return nil
+}
+
+// Update replaces the robot with the same name in the registry.
+func (r *Registry) Update(robot Robot) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.robots[robot.Name]
+	if !ok {
+		return fmt.Errorf("%q: %w", robot.Name, ErrNotFound)
+	}
+
+	err := r.validateLocked(robot, robot.Name)
+	if err != nil {
+		return err
+	}
+
+	r.robots[robot.Name] = &robot
+
+	err = r.saveLocked()
+	if err != nil {
+		r.robots[robot.Name] = old
+		return err
+	}
+
+	return nil
+}
+
+// Remove removes the robot with the given name from the registry.
+func (r *Registry) Remove(name string) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.robots[name]
+	if !ok {
+		return fmt.Errorf("%q: %w", name, ErrNotFound)
+	}
+
+	delete(r.robots, name)
+
+	err := r.saveLocked()
+	if err != nil {
+		r.robots[name] = old
+		return err
+	}
+
+	return nil
+}
+
+// Pair adds a new robot with the given name and Wi-Fi configuration and a newly
+// generated appID to the registry. It returns the robot and the QR code that
+// must be shown to the robot to complete the pairing.
+func (r *Registry) Pair(name string, wifi WiFi, notes string) (Robot,
+	*qrcode.QRCode, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	robot := Robot{
+		Name:  name,
+		WiFi:  wifi,
+		Notes: notes,
+	}
+
+	for robot.AppID == support.AnyAppID || r.appIDInUseLocked(robot.AppID, "") {
+		appID, err := support.GenerateAppID()
+		if err != nil {
+			return Robot{}, nil, err
+		}
+
+		robot.AppID = appID
+	}
+
+	err := r.validateLocked(robot, "")
+	if err != nil {
+		return Robot{}, nil, err
+	}
+
+	qrc, err := robot.QRCode()
+	if err != nil {
+		return Robot{}, nil, err
+	}
+
+	r.robots[robot.Name] = &robot
+
+	err = r.saveLocked()
+	if err != nil {
+		delete(r.robots, robot.Name)
+		return Robot{}, nil, err
+	}
+
+	return robot, qrc, nil
+}
+
+// Observe updates the last known network information of the robot with the
+// appID in the given discovery information (usually obtained with a
+// finder.Discovery). Returns true if a robot was updated.
+func (r *Registry) Observe(ri finder.RobotInfo) (bool, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for _, robot := range r.robots {
+		if robot.AppID != ri.AppID {
+			continue
+		}
+
+		old := *robot
+
+		robot.LastIP = ri.IP.String()
+		robot.LastMAC = ri.MAC.String()
+		robot.LastSeen = ri.LastSeen
+
+		err := r.saveLocked()
+		if err != nil {
+			*robot = old
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *Registry) validateLocked(robot Robot, replacing string) error {
+	if strings.TrimSpace(robot.Name) == "" {
+		return fmt.Errorf("robot name must be non-empty")
+	}
+
+	if replacing == "" {
+		if _, ok := r.robots[robot.Name]; ok {
+			return fmt.Errorf("robot %q already exists", robot.Name)
+		}
+	}
+
+	if robot.AppID == support.AnyAppID {
+		return fmt.Errorf("robot appID must be non-zero")
+	}
+
+	if r.appIDInUseLocked(robot.AppID, replacing) {
+		return fmt.Errorf("appID %d already in use", robot.AppID)
+	}
+
+	return nil
+}
+
+func (r *Registry) appIDInUseLocked(appID uint64, except string) bool {
+	for name, robot := range r.robots {
+		if name != except && robot.AppID == appID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Registry) saveLocked() error {
+	robots := make([]*Robot, 0, len(r.robots))
+	for _, robot := range r.robots {
+		robots = append(robots, robot)
+	}
+
+	sort.Slice(robots, func(i, j int) bool {
+		return robots[i].Name < robots[j].Name
+	})
+
+	data, err := json.MarshalIndent(robots, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it so the registry is never left
+	// half written.
+	f, err := os.CreateTemp(filepath.Dir(r.path), ".robots-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), r.path)
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/support/finder"
+	"github.com/brunoga/robomaster/support/qrcode"
+)
+
+var testWiFi = WiFi{
+	CountryCode: "US",
+	SSID:        "network",
+	Password:    "password",
+}
+
+func TestRegistryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "robots.json")
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Robots()) != 0 {
+		t.Fatalf("expected empty registry")
+	}
+
+	robot, qrc, err := r.Pair("s1", testWiFi, "Red team")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if robot.AppID == 0 || qrc.AppID() != robot.AppID {
+		t.Errorf("unexpected appID %d (QR code %d)", robot.AppID, qrc.AppID())
+	}
+
+	parsed, err := qrcode.NewFromMessage(qrc.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.SsID() != testWiFi.SSID || parsed.Password() != testWiFi.Password {
+		t.Errorf("unexpected QR code: %s", parsed)
+	}
+
+	err = r.Add(Robot{Name: "ep", AppID: 1234, WiFi: testWiFi})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := r.Observe(finder.RobotInfo{
+		IP:       net.ParseIP("192.168.2.10"),
+		MAC:      net.HardwareAddr{1, 2, 3, 4, 5, 6},
+		AppID:    1234,
+		LastSeen: time.Unix(1000, 0),
+	})
+	if err != nil || !ok {
+		t.Fatalf("observe: %t, %v", ok, err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm()&0077 != 0 {
+		t.Errorf("registry file is accessible by others: %s", fi.Mode())
+	}
+
+	r, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	robots := r.Robots()
+	if len(robots) != 2 || robots[0].Name != "ep" || robots[1].Name != "s1" {
+		t.Fatalf("unexpected robots: %+v", robots)
+	}
+
+	if robots[0].LastIP != "192.168.2.10" ||
+		robots[0].LastMAC != "01:02:03:04:05:06" ||
+		!robots[0].LastSeen.Equal(time.Unix(1000, 0)) {
+		t.Errorf("unexpected network information: %+v", robots[0])
+	}
+
+	if robots[1].AppID != robot.AppID || robots[1].Notes != "Red team" {
+		t.Errorf("got %+v, want %+v", robots[1], robot)
+	}
+
+	err = r.Remove("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Get("s1")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestRegistryValidation(t *testing.T) {
+	r, err := Open(filepath.Join(t.TempDir(), "robots.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Add(Robot{Name: "ep", AppID: 1234})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, robot := range []Robot{
+		{Name: " ", AppID: 1},
+		{Name: "ep", AppID: 1},
+		{Name: "s1", AppID: 0},
+		{Name: "s1", AppID: 1234},
+	} {
+		if r.Add(robot) == nil {
+			t.Errorf("expected error adding %+v", robot)
+		}
+	}
+
+	err = r.Update(Robot{Name: "ep", AppID: 1234, Notes: "notes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Update(Robot{Name: "s1", AppID: 1})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+
+	_, _, err = r.Pair("s1", WiFi{}, "")
+	if err == nil {
+		t.Error("expected error pairing with invalid Wi-Fi configuration")
+	}
+
+	if len(r.Robots()) != 1 {
+		t.Errorf("got %d robots, want 1", len(r.Robots()))
+	}
+}
+
+func TestRegistrySaveFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	r, err := Open(filepath.Join(dir, "robots.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Add(Robot{Name: "ep", AppID: 1234, Notes: "notes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving fails as the registry directory is a regular file.
+	notDir := filepath.Join(dir, "file")
+	err = os.WriteFile(notDir, nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.path = filepath.Join(notDir, "robots.json")
+
+	if r.Add(Robot{Name: "s1", AppID: 1}) == nil {
+		t.Error("expected error adding robot")
+	}
+
+	if r.Update(Robot{Name: "ep", AppID: 1234, Notes: "updated"}) == nil {
+		t.Error("expected error updating robot")
+	}
+
+	if r.Remove("ep") == nil {
+		t.Error("expected error removing robot")
+	}
+
+	_, _, err = r.Pair("s1", testWiFi, "")
+	if err == nil {
+		t.Error("expected error pairing robot")
+	}
+
+	ok, err := r.Observe(finder.RobotInfo{
+		IP:       net.ParseIP("192.168.2.10"),
+		AppID:    1234,
+		LastSeen: time.Unix(1000, 0),
+	})
+	if err == nil || ok {
+		t.Errorf("observe: got %t, %v, want error", ok, err)
+	}
+
+	robots := r.Robots()
+	if len(robots) != 1 || robots[0].Notes != "notes" ||
+		robots[0].LastIP != "" {
+		t.Errorf("registry changed after failed saves: %+v", robots)
+	}
+}
@@ -0,0 +1,140 @@
+// Package wav provides a minimal writer for PCM WAV files.
+package wav
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// headerSize is the size of the canonical WAV header.
+const headerSize = 44
+
+// Writer writes PCM samples to a WAV file. The header is written with
+// placeholder sizes on creation and fixed when the Writer is closed, so the
+// underlying writer must support seeking.
+type Writer struct {
+	w io.WriteSeeker
+
+	sampleRate    int
+	channels      int
+	bitsPerSample int
+
+	dataSize uint32
+	closed   bool
+}
+
+// NewWriter returns a new Writer that writes to the given io.WriteSeeker using
+// the given PCM format.
+func NewWriter(w io.WriteSeeker, sampleRate, channels,
+	bitsPerSample int) (*Writer, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: %d", sampleRate)
+	}
+
+	if channels <= 0 || channels > math.MaxUint16 {
+		return nil, fmt.Errorf("invalid channel count: %d", channels)
+	}
+
+	if bitsPerSample != 8 && bitsPerSample != 16 && bitsPerSample != 24 &&
+		bitsPerSample != 32 {
+		return nil, fmt.Errorf("invalid bits per sample: %d", bitsPerSample)
+	}
+
+	ww := &Writer{
+		w:             w,
+		sampleRate:    sampleRate,
+		channels:      channels,
+		bitsPerSample: bitsPerSample,
+	}
+
+	_, err := w.Write(ww.header())
+	if err != nil {
+		return nil, err
+	}
+
+	return ww, nil
+}
+
+// Write writes the given interleaved PCM samples (little endian). The data
+// length must be a multiple of the frame size (channels * bytes per sample).
+func (ww *Writer) Write(pcm []byte) (int, error) {
+	if ww.closed {
+		return 0, fmt.Errorf("writer closed")
+	}
+
+	frameSize := ww.channels * ww.bitsPerSample / 8
+	if len(pcm)%frameSize != 0 {
+		return 0, fmt.Errorf("data length %d is not a multiple of the frame "+
+			"size %d", len(pcm), frameSize)
+	}
+
+	if uint64(ww.dataSize)+uint64(len(pcm)) > math.MaxUint32-headerSize {
+		return 0, fmt.Errorf("maximum WAV file size exceeded")
+	}
+
+	n, err := ww.w.Write(pcm)
+	ww.dataSize += uint32(n)
+
+	return n, err
+}
+
+// Duration returns the duration (in seconds) of the audio written so far.
+func (ww *Writer) Duration() float64 {
+	bytesPerSecond := ww.sampleRate * ww.channels * ww.bitsPerSample / 8
+
+	return float64(ww.dataSize) / float64(bytesPerSecond)
+}
+
+// Close fixes the WAV header sizes. It does not close the underlying writer.
+func (ww *Writer) Close() error {
+	if ww.closed {
+		return fmt.Errorf("writer already closed")
+	}
+
+	ww.closed = true
+
+	end, err := ww.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	_, err = ww.w.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	_, err = ww.w.Write(ww.header())
+	if err != nil {
+		return err
+	}
+
+	_, err = ww.w.Seek(end, io.SeekStart)
+
+	return err
+}
+
+func (ww *Writer) header() []byte {
+	blockAlign := ww.channels * ww.bitsPerSample / 8
+
+	h := make([]byte, 0, headerSize)
+	h = append(h, "RIFF"...)
+	h = binary.LittleEndian.AppendUint32(h, 36+ww.dataSize)
+	h = append(h, "WAVE"...)
+
+	h = append(h, "fmt "...)
+	h = binary.LittleEndian.AppendUint32(h, 16)
+	h = binary.LittleEndian.AppendUint16(h, 1) // PCM.
+	h = binary.LittleEndian.AppendUint16(h, uint16(ww.channels))
+	h = binary.LittleEndian.AppendUint32(h, uint32(ww.sampleRate))
+	h = binary.LittleEndian.AppendUint32(h,
+		uint32(ww.sampleRate*blockAlign))
+	h = binary.LittleEndian.AppendUint16(h, uint16(blockAlign))
+	h = binary.LittleEndian.AppendUint16(h, uint16(ww.bitsPerSample))
+
+	h = append(h, "data"...)
+	h = binary.LittleEndian.AppendUint32(h, ww.dataSize)
+
+	return h
+}
@@ -0,0 +1,89 @@
+package wav
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.wav")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := NewWriter(f, 48000, 1, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pcm := make([]byte, 9600) // 0.1 seconds.
+	for i := 0; i < 2; i++ {
+		if _, err := w.Write(pcm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if d := w.Duration(); d != 0.2 {
+		t.Errorf("unexpected duration: %f", d)
+	}
+
+	if _, err := w.Write(make([]byte, 3)); err == nil {
+		t.Errorf("expected error for partial frame")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) != headerSize+19200 {
+		t.Fatalf("unexpected file size: %d", len(data))
+	}
+
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" ||
+		string(data[36:40]) != "data" {
+		t.Fatalf("invalid WAV header")
+	}
+
+	if riffSize := binary.LittleEndian.Uint32(data[4:]); int(riffSize) !=
+		len(data)-8 {
+		t.Errorf("unexpected RIFF size: %d", riffSize)
+	}
+
+	if dataSize := binary.LittleEndian.Uint32(data[40:]); dataSize != 19200 {
+		t.Errorf("unexpected data size: %d", dataSize)
+	}
+
+	if byteRate := binary.LittleEndian.Uint32(data[28:]); byteRate != 96000 {
+		t.Errorf("unexpected byte rate: %d", byteRate)
+	}
+}
+
+func TestNewWriterInvalidFormat(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := NewWriter(f, 0, 1, 16); err == nil {
+		t.Errorf("expected error for invalid sample rate")
+	}
+
+	if _, err := NewWriter(f, 48000, 0, 16); err == nil {
+		t.Errorf("expected error for invalid channel count")
+	}
+
+	if _, err := NewWriter(f, 48000, 1, 12); err == nil {
+		t.Errorf("expected error for invalid bits per sample")
+	}
+}
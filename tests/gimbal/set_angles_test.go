@@ -0,0 +1,23 @@
+package gimbal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetAbsoluteAnglesSync(t *testing.T) {
+	err := gimbalModule.SetAbsoluteAnglesSync(15, 45, 1*time.Second)
+	if err != nil {
+		t.Errorf("Error setting gimbal absolute angles: %v", err)
+	}
+
+	err = gimbalModule.SetRelativeAnglesSync(-15, 0, 1*time.Second)
+	if err != nil {
+		t.Errorf("Error setting gimbal relative angles: %v", err)
+	}
+
+	err = gimbalModule.SetAbsoluteAnglesSync(0, 0, 1*time.Second)
+	if err != nil {
+		t.Errorf("Error setting gimbal absolute angles: %v", err)
+	}
+}
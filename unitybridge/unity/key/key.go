@@ -38,9 +38,9 @@ var (
 
 	KeyCameraConnection                    = newKey("KeyCameraConnection", 16777217, AccessTypeRead, &value.Bool{})
 	KeyCameraFirmwareVersion               = newKey("KeyCameraFirmwareVersion", 16777218, AccessTypeRead, nil)
-	KeyCameraStartShootPhoto               = newKey("KeyCameraStartShootPhoto", 16777219, AccessTypeAction, nil)
-	KeyCameraIsShootingPhoto               = newKey("KeyCameraIsShootingPhoto", 16777220, AccessTypeRead, nil)
-	KeyCameraPhotoSize                     = newKey("KeyCameraPhotoSize", 16777221, AccessTypeRead|AccessTypeWrite, nil)
+	KeyCameraStartShootPhoto               = newKey("KeyCameraStartShootPhoto", 16777219, AccessTypeAction, &value.Void{})
+	KeyCameraIsShootingPhoto               = newKey("KeyCameraIsShootingPhoto", 16777220, AccessTypeRead, &value.Bool{})
+	KeyCameraPhotoSize                     = newKey("KeyCameraPhotoSize", 16777221, AccessTypeRead|AccessTypeWrite, &value.Uint64{})
 	KeyCameraStartRecordVideo              = newKey("KeyCameraStartRecordVideo", 16777222, AccessTypeAction, &value.Void{})
 	KeyCameraStopRecordVideo               = newKey("KeyCameraStopRecordVideo", 16777223, AccessTypeAction, &value.Void{})
 	KeyCameraIsRecording                   = newKey("KeyCameraIsRecording", 16777224, AccessTypeRead, &value.Bool{})
@@ -48,15 +48,15 @@ var (
 	KeyCameraVideoFormat                   = newKey("KeyCameraVideoFormat", 16777226, AccessTypeRead|AccessTypeWrite, &value.Uint64{})
 	KeyCameraMode                          = newKey("KeyCameraMode", 16777227, AccessTypeRead|AccessTypeWrite, &value.Uint64{})
 	KeyCameraDigitalZoomFactor             = newKey("KeyCameraDigitalZoomFactor", 16777228, AccessTypeRead|AccessTypeWrite, &value.Uint64{})
-	KeyCameraAntiFlicker                   = newKey("KeyCameraAntiFlicker", 16777229, AccessTypeRead|AccessTypeWrite, nil)
-	KeyCameraSwitch                        = newKey("KeyCameraSwitch", 16777230, AccessTypeAction, nil)
-	KeyCameraCurrentCameraIndex            = newKey("KeyCameraCurrentCameraIndex", 16777231, AccessTypeRead, nil)
-	KeyCameraHasMainCamera                 = newKey("KeyCameraHasMainCamera", 16777232, AccessTypeRead, nil)
-	KeyCameraHasSecondaryCamera            = newKey("KeyCameraHasSecondaryCamera", 16777233, AccessTypeRead, nil)
-	KeyCameraIsTimeSynced                  = newKey("KeyCameraIsTimeSynced", 16777243, AccessTypeRead, nil)
-	KeyCameraDate                          = newKey("KeyCameraDate", 16777244, AccessTypeRead|AccessTypeWrite, nil)
+	KeyCameraAntiFlicker                   = newKey("KeyCameraAntiFlicker", 16777229, AccessTypeRead|AccessTypeWrite, &value.Uint64{})
+	KeyCameraSwitch                        = newKey("KeyCameraSwitch", 16777230, AccessTypeAction, &value.Void{})
+	KeyCameraCurrentCameraIndex            = newKey("KeyCameraCurrentCameraIndex", 16777231, AccessTypeRead, &value.Uint64{})
+	KeyCameraHasMainCamera                 = newKey("KeyCameraHasMainCamera", 16777232, AccessTypeRead, &value.Bool{})
+	KeyCameraHasSecondaryCamera            = newKey("KeyCameraHasSecondaryCamera", 16777233, AccessTypeRead, &value.Bool{})
+	KeyCameraIsTimeSynced                  = newKey("KeyCameraIsTimeSynced", 16777243, AccessTypeRead, &value.Bool{})
+	KeyCameraDate                          = newKey("KeyCameraDate", 16777244, AccessTypeRead|AccessTypeWrite, &value.CameraDate{})
 	KeyCameraVideoTransRate                = newKey("KeyCameraVideoTransRate", 16777245, AccessTypeWrite, &value.Float64{})
-	KeyCameraRequestIFrame                 = newKey("KeyCameraRequestIFrame", 16777246, AccessTypeAction, nil)
+	KeyCameraRequestIFrame                 = newKey("KeyCameraRequestIFrame", 16777246, AccessTypeAction, &value.Void{})
 	KeyCameraAntiLarsenAlgorithmEnable     = newKey("KeyCameraAntiLarsenAlgorithmEnable", 16777247, AccessTypeWrite, nil)
 
 	KeyCameraFormatSDCard                          = newKey("KeyCameraFormatSDCard", 16777234, AccessTypeAction, &value.Void{})
@@ -160,7 +160,7 @@ var (
 	KeyRobomasterSystemEquipments                       = newKey("KeyRobomasterSystemEquipments", 83886121, AccessTypeRead, nil)
 	KeyRobomasterSystemBuffs                            = newKey("KeyRobomasterSystemBuffs", 83886122, AccessTypeRead, nil)
 	KeyRobomasterSystemSkillStatus                      = newKey("KeyRobomasterSystemSkillStatus", 83886123, AccessTypeRead, nil)
-	KeyRobomasterSystemGunCoolDown                      = newKey("KeyRobomasterSystemGunCoolDown", 83886124, AccessTypeRead, nil)
+	KeyRobomasterSystemGunCoolDown                      = newKey("KeyRobomasterSystemGunCoolDown", 83886124, AccessTypeRead, &value.Bool{})
 	KeyRobomasterSystemGameConfigList                   = newKey("KeyRobomasterSystemGameConfigList", 83886125, AccessTypeWrite, nil)
 	KeyRobomasterSystemCarAndSkillID                    = newKey("KeyRobomasterSystemCarAndSkillID", 83886126, AccessTypeWrite, nil)
 	KeyRobomasterSystemAppStatus                        = newKey("KeyRobomasterSystemAppStatus", 83886127, AccessTypeWrite, nil)
@@ -173,7 +173,7 @@ var (
 	KeyRobomasterSystemReturnEnabled                    = newKey("KeyRobomasterSystemReturnEnabled", 83886134, AccessTypeRead|AccessTypeWrite, nil)
 	KeyRobomasterSystemSafeMode                         = newKey("KeyRobomasterSystemSafeMode", 83886135, AccessTypeRead|AccessTypeWrite, nil)
 	KeyRobomasterSystemScratchExecuteState              = newKey("KeyRobomasterSystemScratchExecuteState", 83886136, AccessTypeRead, nil)
-	KeyRobomasterSystemAttitudeInfo                     = newKey("KeyRobomasterSystemAttitudeInfo", 83886137, AccessTypeRead, nil)
+	KeyRobomasterSystemAttitudeInfo                     = newKey("KeyRobomasterSystemAttitudeInfo", 83886137, AccessTypeRead, &value.ChassisAttitude{})
 	KeyRobomasterSystemSightBeadPosition                = newKey("KeyRobomasterSystemSightBeadPosition", 83886138, AccessTypeRead|AccessTypeWrite, nil)
 	KeyRobomasterSystemSpeakerLanguage                  = newKey("KeyRobomasterSystemSpeakerLanguage", 83886139, AccessTypeRead|AccessTypeWrite, nil)
 	KeyRobomasterSystemSpeakerVolumn                    = newKey("KeyRobomasterSystemSpeakerVolumn", 83886140, AccessTypeRead|AccessTypeWrite, &value.Uint64{})
@@ -181,8 +181,8 @@ var (
 	KeyRobomasterSystemIsEncryptedFirmware              = newKey("KeyRobomasterSystemIsEncryptedFirmware", 83886142, AccessTypeRead, nil)
 	KeyRobomasterSystemScratchErrorInfo                 = newKey("KeyRobomasterSystemScratchErrorInfo", 83886143, AccessTypeRead, nil)
 	KeyRobomasterSystemScratchOutputInfo                = newKey("KeyRobomasterSystemScratchOutputInfo", 83886144, AccessTypeRead, nil)
-	KeyRobomasterSystemBarrelCoolDown                   = newKey("KeyRobomasterSystemBarrelCoolDown", 83886145, AccessTypeAction, nil)
-	KeyRobomasterSystemResetBarrelOverheat              = newKey("KeyRobomasterSystemResetBarrelOverheat", 83886146, AccessTypeAction, nil)
+	KeyRobomasterSystemBarrelCoolDown                   = newKey("KeyRobomasterSystemBarrelCoolDown", 83886145, AccessTypeAction, &value.Void{})
+	KeyRobomasterSystemResetBarrelOverheat              = newKey("KeyRobomasterSystemResetBarrelOverheat", 83886146, AccessTypeAction, &value.Void{})
 	KeyRobomasterSystemMobileAccelerInfo                = newKey("KeyRobomasterSystemMobileAccelerInfo", 83886147, AccessTypeWrite, nil)
 	KeyRobomasterSystemMobileGyroAttitudeAngleInfo      = newKey("KeyRobomasterSystemMobileGyroAttitudeAngleInfo", 83886148, AccessTypeWrite, nil)
 	KeyRobomasterSystemMobileGyroRotationRateInfo       = newKey("KeyRobomasterSystemMobileGyroRotationRateInfo", 83886149, AccessTypeWrite, nil)
@@ -211,16 +211,16 @@ var (
 	KeyRobomasterSystemOpenImageTransmission            = newKey("KeyRobomasterSystemOpenImageTransmission", 83886172, AccessTypeAction, nil)
 	KeyRobomasterSystemCloseImageTransmission           = newKey("KeyRobomasterSystemCloseImageTransmission", 83886173, AccessTypeAction, nil)
 
-	KeyRobomasterWaterGunFirmwareVersion       = newKey("KeyRobomasterWaterGunFirmwareVersion", 167772161, AccessTypeRead, nil)
+	KeyRobomasterWaterGunFirmwareVersion       = newKey("KeyRobomasterWaterGunFirmwareVersion", 167772161, AccessTypeRead, &value.String{})
 	KeyRobomasterWaterGunWaterGunFire          = newKey("KeyRobomasterWaterGunWaterGunFire", 167772162, AccessTypeAction, nil)
-	KeyRobomasterWaterGunWaterGunFireWithTimes = newKey("KeyRobomasterWaterGunWaterGunFireWithTimes", 167772163, AccessTypeAction, nil)
-	KeyRobomasterWaterGunShootSpeed            = newKey("KeyRobomasterWaterGunShootSpeed", 167772164, AccessTypeRead, nil)
-	KeyRobomasterWaterGunShootFrequency        = newKey("KeyRobomasterWaterGunShootFrequency", 167772165, AccessTypeRead, nil)
+	KeyRobomasterWaterGunWaterGunFireWithTimes = newKey("KeyRobomasterWaterGunWaterGunFireWithTimes", 167772163, AccessTypeAction, &value.Uint64{})
+	KeyRobomasterWaterGunShootSpeed            = newKey("KeyRobomasterWaterGunShootSpeed", 167772164, AccessTypeRead|AccessTypeWrite /*Added*/, &value.Uint64{})
+	KeyRobomasterWaterGunShootFrequency        = newKey("KeyRobomasterWaterGunShootFrequency", 167772165, AccessTypeRead|AccessTypeWrite /*Added*/, &value.Uint64{})
 
-	KeyRobomasterInfraredGunConnection      = newKey("KeyRobomasterInfraredGunConnection", 301989889, AccessTypeRead, nil)
-	KeyRobomasterInfraredGunFirmwareVersion = newKey("KeyRobomasterInfraredGunFirmwareVersion", 301989890, AccessTypeRead, nil)
+	KeyRobomasterInfraredGunConnection      = newKey("KeyRobomasterInfraredGunConnection", 301989889, AccessTypeRead, &value.Bool{})
+	KeyRobomasterInfraredGunFirmwareVersion = newKey("KeyRobomasterInfraredGunFirmwareVersion", 301989890, AccessTypeRead, &value.String{})
 	KeyRobomasterInfraredGunInfraredGunFire = newKey("KeyRobomasterInfraredGunInfraredGunFire", 301989891, AccessTypeAction, nil)
-	KeyRobomasterInfraredGunShootFrequency  = newKey("KeyRobomasterInfraredGunShootFrequency", 301989892, AccessTypeRead, nil)
+	KeyRobomasterInfraredGunShootFrequency  = newKey("KeyRobomasterInfraredGunShootFrequency", 301989892, AccessTypeRead|AccessTypeWrite /*Added*/, &value.Uint64{})
 
 	KeyRobomasterBatteryFirmwareVersion = newKey("KeyRobomasterBatteryFirmwareVersion", 218103809, AccessTypeRead, nil)
 	KeyRobomasterBatteryPowerPercent    = newKey("KeyRobomasterBatteryPowerPercent", 218103810, AccessTypeRead, &value.Uint64{})
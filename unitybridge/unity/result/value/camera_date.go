@@ -0,0 +1,12 @@
+package value
+
+// CameraDate is the date and time used by the camera (for example, to
+// timestamp photos and videos stored in the SD card).
+type CameraDate struct {
+	Year   int `json:"year"`
+	Month  int `json:"month"`
+	Day    int `json:"day"`
+	Hour   int `json:"hour"`
+	Minute int `json:"minute"`
+	Second int `json:"second"`
+}
@@ -0,0 +1,7 @@
+package value
+
+type ChassisAttitude struct {
+	Pitch float32 `json:"pitch"`
+	Roll  float32 `json:"roll"`
+	Yaw   float32 `json:"yaw"`
+}
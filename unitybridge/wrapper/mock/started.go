@@ -0,0 +1,71 @@
+package mock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/stretchr/testify/mock"
+)
+
+// NewStartedUnityBridge returns a started high level Unity Bridge backed by a
+// new mock that accepts any event sent to it. Set value and perform action
+// events that expect a result are answered with a successful one. Events sent
+// can be inspected with Sent and events can be generated with GenerateEvent.
+// The Unity Bridge is stopped when the test ends.
+func NewStartedUnityBridge(t testing.TB) (*UnityBridge,
+	unitybridge.UnityBridge) {
+	uw := NewUnityBridgeWrapper()
+	ub := unitybridge.Get(uw, false, nil)
+
+	uw.On("Create", "Robomaster", false, "")
+	uw.On("Initialize").Return(true)
+	uw.On("SetEventCallback", mock.Anything, mock.Anything)
+	uw.On("SendEvent", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).Run(uw.reply)
+	uw.On("SendEventWithString", mock.Anything, mock.Anything, mock.Anything).
+		Run(uw.reply)
+	uw.On("SendEventWithNumber", mock.Anything, mock.Anything, mock.Anything)
+	uw.On("Uninitialize")
+	uw.On("Destroy")
+
+	err := ub.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		ub.Stop()
+	})
+
+	return uw, ub
+}
+
+// reply generates a successful result for set value and perform action events
+// that expect one (i.e. that have a tag).
+func (w *UnityBridge) reply(args mock.Arguments) {
+	eventCode := args.Get(0).(uint64)
+	tag := args.Get(2).(uint64)
+
+	e := event.NewFromCode(eventCode)
+	if tag == 0 || (e.Type() != event.TypeSetValue &&
+		e.Type() != event.TypePerformAction) {
+		return
+	}
+
+	data, err := json.Marshal(struct {
+		Key   uint32
+		Tag   uint64
+		Error int64
+		Value string
+	}{
+		Key: e.SubType(),
+		Tag: tag,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	w.GenerateEvent(eventCode, data, tag)
+}
@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"sync"
+
 	"github.com/brunoga/robomaster/unitybridge/wrapper/callback"
 	"github.com/stretchr/testify/mock"
 
@@ -11,6 +13,18 @@ type UnityBridge struct {
 	mock.Mock
 
 	cm *internal_callback.Manager
+
+	sm   sync.Mutex
+	sent []SentEvent
+}
+
+// SentEvent is an event sent through the mock. Only one of String and Number
+// is set, depending on the method used to send it.
+type SentEvent struct {
+	Code   uint64
+	String string
+	Number uint64
+	Tag    uint64
 }
 
 func NewUnityBridgeWrapper() *UnityBridge {
@@ -38,6 +52,8 @@ func (w *UnityBridge) SetEventCallback(eventTypeCode uint64,
 
 func (w *UnityBridge) SendEvent(eventCode uint64, output []byte,
 	tag uint64) {
+	w.record(SentEvent{Code: eventCode, Tag: tag})
+
 	args := w.Called(eventCode, output, tag)
 	if len(output) > 0 && args.Get(0) != nil {
 		copy(output, args.Get(0).([]byte))
@@ -46,11 +62,15 @@ func (w *UnityBridge) SendEvent(eventCode uint64, output []byte,
 
 func (w *UnityBridge) SendEventWithString(eventCode uint64, data string,
 	tag uint64) {
+	w.record(SentEvent{Code: eventCode, String: data, Tag: tag})
+
 	w.Called(eventCode, data, tag)
 }
 
 func (w *UnityBridge) SendEventWithNumber(eventCode uint64, data,
 	tag uint64) {
+	w.record(SentEvent{Code: eventCode, Number: data, Tag: tag})
+
 	w.Called(eventCode, data, tag)
 }
 
@@ -74,3 +94,21 @@ func (w *UnityBridge) GenerateEvent(eventCode uint64, data []byte,
 	tag uint64) error {
 	return w.cm.Run(eventCode, data, tag)
 }
+
+// Sent returns all events sent through the mock so far, in order.
+func (w *UnityBridge) Sent() []SentEvent {
+	w.sm.Lock()
+	defer w.sm.Unlock()
+
+	sent := make([]SentEvent, len(w.sent))
+	copy(sent, w.sent)
+
+	return sent
+}
+
+func (w *UnityBridge) record(e SentEvent) {
+	w.sm.Lock()
+	defer w.sm.Unlock()
+
+	w.sent = append(w.sent, e)
+}